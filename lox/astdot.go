@@ -0,0 +1,88 @@
+package lox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// astDot renders the AST as a Graphviz DOT digraph. It walks the same maps
+// as astJSON, so both formats always agree on what the tree looks like.
+// Every node is labelled with its type and its scalar fields, and edges to
+// the children are labelled with the field they are stored in.
+func astDot(statements []statement) string {
+	d := &dotWriter{}
+	d.builder.WriteString("digraph AST {\n")
+	d.builder.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	d.node(programToMap(statements))
+	d.builder.WriteString("}\n")
+	return d.builder.String()
+}
+
+type dotWriter struct {
+	builder strings.Builder
+	count   int
+}
+
+// node writes the node and everything below it, and returns its id
+func (d *dotWriter) node(m map[string]any) string {
+	id := fmt.Sprintf("n%d", d.count)
+	d.count++
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		if key != "type" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	label := []string{fmt.Sprint(m["type"])}
+	type edge struct {
+		label string
+		child map[string]any
+	}
+	var edges []edge
+
+	for _, key := range keys {
+		switch value := m[key].(type) {
+		case map[string]any:
+			if value != nil {
+				edges = append(edges, edge{key, value})
+			}
+		case []any:
+			var scalars []string
+			for i, element := range value {
+				if child, ok := element.(map[string]any); ok {
+					edges = append(edges, edge{fmt.Sprintf("%s[%d]", key, i), child})
+				} else {
+					scalars = append(scalars, fmt.Sprint(element))
+				}
+			}
+			if len(scalars) > 0 {
+				label = append(label, key+": "+strings.Join(scalars, ", "))
+			}
+		case string:
+			label = append(label, fmt.Sprintf("%s: %q", key, value))
+		case nil:
+			label = append(label, key+": nil")
+		default:
+			label = append(label, fmt.Sprintf("%s: %s", key, stringify(value)))
+		}
+	}
+
+	fmt.Fprintf(&d.builder, "  %s [label=%s];\n", id, dotQuote(strings.Join(label, "\n")))
+	for _, e := range edges {
+		childID := d.node(e.child)
+		fmt.Fprintf(&d.builder, "  %s -> %s [label=%s];\n", id, childID, dotQuote(e.label))
+	}
+	return id
+}
+
+// dotQuote quotes a string for use as a DOT attribute value
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,292 @@
+package lox
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The JSON schema for the AST. Every node is an object with a "type" key
+// holding the name of the node (e.g. "Binary", "PrintStmt"), and one key
+// per field of that node:
+//
+//   - child nodes are objects, lists of children are arrays, and missing
+//     optional children (like an absent else branch) are null
+//   - tokens are stored as their lexeme, e.g. "operator": "+", with the
+//     line of the token in "line"
+//   - literal values are JSON numbers, strings, booleans or null
+//
+// The whole program is {"type": "Program", "statements": [...]}.
+// Keys are written in sorted order, so the output is stable.
+
+func astJSON(statements []statement) (string, error) {
+	res, err := json.MarshalIndent(programToMap(statements), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshalling ast: %w", err)
+	}
+	return string(res), nil
+}
+
+func programToMap(statements []statement) map[string]any {
+	return map[string]any{
+		"type":       "Program",
+		"statements": stmtsToMaps(statements),
+	}
+}
+
+func stmtsToMaps(stmts []statement) []any {
+	res := make([]any, 0, len(stmts))
+	for _, stmt := range stmts {
+		res = append(res, stmtToMap(stmt))
+	}
+	return res
+}
+
+func exprsToMaps(exprs []expression) []any {
+	res := make([]any, 0, len(exprs))
+	for _, expr := range exprs {
+		res = append(res, exprToMap(expr))
+	}
+	return res
+}
+
+func exprToMap(expr expression) map[string]any {
+	switch t := expr.(type) {
+	case nil:
+		return nil
+	case *assignExpr:
+		return map[string]any{
+			"type":  "Assign",
+			"name":  t.name.lexeme,
+			"line":  t.name.line,
+			"value": exprToMap(t.value),
+		}
+	case *binaryExpr:
+		return map[string]any{
+			"type":     "Binary",
+			"operator": t.operator.lexeme,
+			"line":     t.operator.line,
+			"left":     exprToMap(t.left),
+			"right":    exprToMap(t.right),
+		}
+	case *callExpr:
+		return map[string]any{
+			"type":      "Call",
+			"line":      t.paren.line,
+			"callee":    exprToMap(t.callee),
+			"arguments": exprsToMaps(t.arguments),
+		}
+	case *getExpr:
+		return map[string]any{
+			"type":   "Get",
+			"name":   t.name.lexeme,
+			"line":   t.name.line,
+			"object": exprToMap(t.object),
+		}
+	case *groupingExpr:
+		return map[string]any{
+			"type":       "Grouping",
+			"expression": exprToMap(t.expression),
+		}
+	case *indexExpr:
+		return map[string]any{
+			"type":   "Index",
+			"line":   t.bracket.line,
+			"object": exprToMap(t.object),
+			"index":  exprToMap(t.index),
+		}
+	case *interpolationExpr:
+		return map[string]any{
+			"type":  "Interpolation",
+			"parts": exprsToMaps(t.parts),
+		}
+	case *listExpr:
+		return map[string]any{
+			"type":     "List",
+			"elements": exprsToMaps(t.elements),
+		}
+	case *literalExpr:
+		return map[string]any{
+			"type":  "Literal",
+			"value": t.value,
+		}
+	case *logicalExpr:
+		return map[string]any{
+			"type":     "Logical",
+			"operator": t.operator.lexeme,
+			"line":     t.operator.line,
+			"left":     exprToMap(t.left),
+			"right":    exprToMap(t.right),
+		}
+	case *mapExpr:
+		return map[string]any{
+			"type":   "Map",
+			"keys":   exprsToMaps(t.keys),
+			"values": exprsToMaps(t.values),
+		}
+	case *setExpr:
+		return map[string]any{
+			"type":   "Set",
+			"name":   t.name.lexeme,
+			"line":   t.name.line,
+			"object": exprToMap(t.object),
+			"value":  exprToMap(t.value),
+		}
+	case *setIndexExpr:
+		return map[string]any{
+			"type":   "SetIndex",
+			"line":   t.bracket.line,
+			"object": exprToMap(t.object),
+			"index":  exprToMap(t.index),
+			"value":  exprToMap(t.value),
+		}
+	case *superExpr:
+		return map[string]any{
+			"type":   "Super",
+			"method": t.method.lexeme,
+			"line":   t.keyword.line,
+		}
+	case *thisExpr:
+		return map[string]any{
+			"type": "This",
+			"line": t.keyword.line,
+		}
+	case *unaryExpr:
+		return map[string]any{
+			"type":     "Unary",
+			"operator": t.operator.lexeme,
+			"line":     t.operator.line,
+			"right":    exprToMap(t.right),
+		}
+	case *variableExpr:
+		return map[string]any{
+			"type": "Variable",
+			"name": t.name.lexeme,
+			"line": t.name.line,
+		}
+	default:
+		panic(fmt.Sprintf("unknown type %T: %v", expr, t))
+	}
+}
+
+func stmtToMap(stmt statement) map[string]any {
+	switch t := stmt.(type) {
+	case nil:
+		return nil
+	case blockStmt:
+		return map[string]any{
+			"type":       "BlockStmt",
+			"statements": stmtsToMaps(t.statements),
+		}
+	case classStmt:
+		var superclass map[string]any = nil
+		if t.superclass != nil {
+			superclass = exprToMap(t.superclass)
+		}
+		methods := make([]any, 0, len(t.methods))
+		for _, method := range t.methods {
+			methods = append(methods, stmtToMap(method))
+		}
+		return map[string]any{
+			"type":       "ClassStmt",
+			"name":       t.name.lexeme,
+			"line":       t.name.line,
+			"superclass": superclass,
+			"methods":    methods,
+		}
+	case expressionStmt:
+		return map[string]any{
+			"type":       "ExpressionStmt",
+			"expression": exprToMap(t.expression),
+		}
+	case functionStmt:
+		params := make([]any, 0, len(t.params))
+		for _, param := range t.params {
+			params = append(params, param.lexeme)
+		}
+		return map[string]any{
+			"type":   "FunctionStmt",
+			"name":   t.name.lexeme,
+			"line":   t.name.line,
+			"params": params,
+			"body":   stmtsToMaps(t.body),
+		}
+	case ifStmt:
+		return map[string]any{
+			"type":       "IfStmt",
+			"condition":  exprToMap(t.condition),
+			"thenBranch": stmtToMap(t.thenBranch),
+			"elseBranch": stmtToMap(t.elseBranch),
+		}
+	case printStmt:
+		return map[string]any{
+			"type":       "PrintStmt",
+			"expression": exprToMap(t.expression),
+		}
+	case returnStmt:
+		return map[string]any{
+			"type":  "ReturnStmt",
+			"line":  t.keyword.line,
+			"value": exprToMap(t.value),
+		}
+	case varStmt:
+		return map[string]any{
+			"type":        "VarStmt",
+			"name":        t.name.lexeme,
+			"line":        t.name.line,
+			"initializer": exprToMap(t.initializer),
+		}
+	case importStmt:
+		return map[string]any{
+			"type": "ImportStmt",
+			"path": t.path.literal,
+			"name": t.name.lexeme,
+			"line": t.keyword.line,
+		}
+	case whileStmt:
+		return map[string]any{
+			"type":      "WhileStmt",
+			"condition": exprToMap(t.condition),
+			"body":      stmtToMap(t.body),
+			"increment": exprToMap(t.increment),
+		}
+	case throwStmt:
+		return map[string]any{
+			"type":  "ThrowStmt",
+			"line":  t.keyword.line,
+			"value": exprToMap(t.value),
+		}
+	case tryStmt:
+		var catch map[string]any
+		if t.catch != nil {
+			catch = map[string]any{
+				"type": "CatchClause",
+				"name": t.catch.name.lexeme,
+				"line": t.catch.name.line,
+				"body": stmtsToMaps(t.catch.body),
+			}
+		}
+		var finally map[string]any
+		if t.finally != nil {
+			finally = stmtToMap(*t.finally)
+		}
+		return map[string]any{
+			"type":    "TryStmt",
+			"line":    t.keyword.line,
+			"body":    stmtToMap(t.body),
+			"catch":   catch,
+			"finally": finally,
+		}
+	case breakStmt:
+		return map[string]any{
+			"type": "BreakStmt",
+			"line": t.keyword.line,
+		}
+	case continueStmt:
+		return map[string]any{
+			"type": "ContinueStmt",
+			"line": t.keyword.line,
+		}
+	default:
+		panic(fmt.Sprintf("unknown type %T: %v", stmt, t))
+	}
+}
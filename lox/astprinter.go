@@ -0,0 +1,187 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// astPrint prints an expression as a Lisp-style S-expression
+// https://craftinginterpreters.com/representing-code.html#a-not-very-pretty-printer
+func astPrint(expr expression) string {
+	switch t := expr.(type) {
+	case *assignExpr:
+		return parenthesize("=", t.name.lexeme, t.value)
+	case *binaryExpr:
+		return printBinary(t)
+	case *callExpr:
+		return parenthesize("call", append([]any{t.callee}, exprsToAny(t.arguments)...)...)
+	case *getExpr:
+		return parenthesize(".", t.object, t.name.lexeme)
+	case *groupingExpr:
+		return printGrouping(t)
+	case *indexExpr:
+		return parenthesize("[]", t.object, t.index)
+	case *interpolationExpr:
+		return parenthesize("interpolate", exprsToAny(t.parts)...)
+	case *listExpr:
+		return parenthesize("list", exprsToAny(t.elements)...)
+	case *literalExpr:
+		return printLiteral(t)
+	case *logicalExpr:
+		return parenthesize(t.operator.lexeme, t.left, t.right)
+	case *mapExpr:
+		var entries []any
+		for n := range t.keys {
+			entries = append(entries, t.keys[n], t.values[n])
+		}
+		return parenthesize("map", entries...)
+	case *setExpr:
+		return parenthesize("=", parenthesize(".", t.object, t.name.lexeme), t.value)
+	case *setIndexExpr:
+		return parenthesize("=", parenthesize("[]", t.object, t.index), t.value)
+	case *superExpr:
+		return parenthesize("super", t.method.lexeme)
+	case *thisExpr:
+		return "this"
+	case *unaryExpr:
+		return printUnary(t)
+	case *variableExpr:
+		return t.name.lexeme
+	default:
+		panic(fmt.Sprintf("unknown type %T: %v", expr, t))
+	}
+}
+
+// astPrintStmt prints a statement as a Lisp-style S-expression
+func astPrintStmt(stmt statement) string {
+	switch t := stmt.(type) {
+	case blockStmt:
+		return parenthesize("block", stmtsToAny(t.statements)...)
+	case classStmt:
+		parts := []any{t.name.lexeme}
+		if t.superclass != nil {
+			parts = append(parts, "<", t.superclass)
+		}
+		for _, method := range t.methods {
+			parts = append(parts, method)
+		}
+		return parenthesize("class", parts...)
+	case expressionStmt:
+		return parenthesize(";", t.expression)
+	case functionStmt:
+		params := make([]string, 0, len(t.params))
+		for _, param := range t.params {
+			params = append(params, param.lexeme)
+		}
+		parts := []any{t.name.lexeme, "(" + strings.Join(params, " ") + ")"}
+		return parenthesize("fun", append(parts, stmtsToAny(t.body)...)...)
+	case ifStmt:
+		return parenthesize("if", t.condition, t.thenBranch, t.elseBranch)
+	case printStmt:
+		return parenthesize("print", t.expression)
+	case returnStmt:
+		return parenthesize("return", t.value)
+	case varStmt:
+		return parenthesize("var", t.name.lexeme, t.initializer)
+	case importStmt:
+		return parenthesize("import", t.path.lexeme, t.name.lexeme)
+	case whileStmt:
+		return parenthesize("while", t.condition, t.body, t.increment)
+	case throwStmt:
+		return parenthesize("throw", t.value)
+	case tryStmt:
+		parts := []any{t.body}
+		if t.catch != nil {
+			catch := append([]any{t.catch.name.lexeme}, stmtsToAny(t.catch.body)...)
+			parts = append(parts, parenthesize("catch", catch...))
+		}
+		if t.finally != nil {
+			parts = append(parts, parenthesize("finally", *t.finally))
+		}
+		return parenthesize("try", parts...)
+	case breakStmt:
+		return parenthesize("break")
+	case continueStmt:
+		return parenthesize("continue")
+	default:
+		panic(fmt.Sprintf("unknown type %T: %v", stmt, t))
+	}
+}
+
+// astPrintProgram prints every statement on a line of its own
+func astPrintProgram(statements []statement) string {
+	var builder strings.Builder
+	for _, statement := range statements {
+		builder.WriteString(astPrintStmt(statement))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// parenthesize wraps the parts in parentheses after the name. A part can be an
+// expression, a statement or a string that is printed as is. Missing optional
+// parts, like an absent else branch, are left out.
+func parenthesize(name string, parts ...any) string {
+	var builder strings.Builder
+	builder.WriteString("(")
+	builder.WriteString(name)
+	for _, part := range parts {
+		var s string
+		switch t := part.(type) {
+		case expression:
+			s = astPrint(t)
+		case statement:
+			s = astPrintStmt(t)
+		case string:
+			s = t
+		case nil:
+			continue
+		default:
+			panic(fmt.Sprintf("parenthesize: unknown part %T: %v", part, t))
+		}
+		builder.WriteString(" ")
+		builder.WriteString(s)
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func exprsToAny(exprs []expression) []any {
+	res := make([]any, 0, len(exprs))
+	for _, expr := range exprs {
+		res = append(res, expr)
+	}
+	return res
+}
+
+func stmtsToAny(stmts []statement) []any {
+	res := make([]any, 0, len(stmts))
+	for _, stmt := range stmts {
+		res = append(res, stmt)
+	}
+	return res
+}
+
+func printBinary(expr *binaryExpr) string {
+	return parenthesize(expr.operator.lexeme, expr.left, expr.right)
+}
+
+func printGrouping(expr *groupingExpr) string {
+	return parenthesize("group", expr.expression)
+}
+
+func printLiteral(expr *literalExpr) string {
+	if expr.value == nil {
+		return "nil"
+	}
+	// Quote strings, so they can be told apart from identifiers
+	if s, ok := expr.value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	// TODO: Better way to do it than through fmt?
+	return fmt.Sprintf("%v", expr.value)
+}
+
+func printUnary(expr *unaryExpr) string {
+	return parenthesize(expr.operator.lexeme, expr.right)
+}
@@ -0,0 +1,78 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Execution budgets let a host stop scripts that run for too long, e.g. a
+// `while (true) {}` in a snippet a user sent. The interpreter takes a step
+// for every statement it executes, every loop iteration and every call, and
+// checks the budget on each one.
+
+// ErrStepLimit is the cause of a BudgetError when the script took more steps than allowed
+var ErrStepLimit = errors.New("step limit exceeded")
+
+// budgetCheckInterval is how many steps there are between checking the
+// deadline and the context, which are too slow to check on every step
+const budgetCheckInterval = 256
+
+// BudgetError means the script was stopped because it ran out of budget, and
+// not because of an error in it. It unwraps to ErrStepLimit,
+// context.DeadlineExceeded or context.Canceled.
+type BudgetError struct {
+	// span is where the script was when it was stopped
+	span Span
+	err  error
+}
+
+func (b BudgetError) Error() string {
+	return fmt.Sprintf("%v: execution stopped: %v", b.span, b.err)
+}
+
+func (b BudgetError) Unwrap() error {
+	return b.err
+}
+
+// WithContext stops the script when the context is cancelled or its deadline passes
+func WithContext(ctx context.Context) InterpreterOption {
+	return func(i *Interpreter) {
+		i.ctx = ctx
+	}
+}
+
+// WithMaxSteps stops the script after it has taken this many steps in a single
+// call to interpret. Zero means there is no limit.
+func WithMaxSteps(steps int) InterpreterOption {
+	return func(i *Interpreter) {
+		i.maxSteps = steps
+	}
+}
+
+// WithDeadline stops the script when the deadline passes. The zero time means there is no deadline.
+func WithDeadline(deadline time.Time) InterpreterOption {
+	return func(i *Interpreter) {
+		i.deadline = deadline
+	}
+}
+
+// step takes a step at the span, and returns a BudgetError if the budget ran out
+func (i *Interpreter) step(span Span) error {
+	i.steps++
+	if i.maxSteps > 0 && i.steps > i.maxSteps {
+		return BudgetError{span: span, err: ErrStepLimit}
+	}
+
+	if i.steps%budgetCheckInterval != 0 {
+		return nil
+	}
+	if err := i.ctx.Err(); err != nil {
+		return BudgetError{span: span, err: err}
+	}
+	if !i.deadline.IsZero() && time.Now().After(i.deadline) {
+		return BudgetError{span: span, err: context.DeadlineExceeded}
+	}
+	return nil
+}
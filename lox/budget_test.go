@@ -0,0 +1,71 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+const infiniteLoop = `var i = 0; while (true) { i = i + 1; }`
+
+// budgetError returns the BudgetError in err, which must not be a RuntimeError
+func budgetError(t *testing.T, err error) BudgetError {
+	t.Helper()
+	var budgetErr BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected a BudgetError, got %v", err)
+	}
+	var runtimeErr RuntimeError
+	if errors.As(err, &runtimeErr) {
+		t.Errorf("a BudgetError is also a RuntimeError: %v", err)
+	}
+	return budgetErr
+}
+
+func TestWithMaxSteps(t *testing.T) {
+	interpreter := New(WithStderr(io.Discard), WithMaxSteps(1000))
+	err := interpreter.Eval(infiniteLoop)
+	if budgetErr := budgetError(t, err); !errors.Is(budgetErr, ErrStepLimit) {
+		t.Errorf("got %v, want it to wrap ErrStepLimit", budgetErr)
+	}
+
+	// Scripts can't catch it, and every Eval gets the full budget
+	err = interpreter.Eval(`try { while (true) {} } catch (e) { print "caught"; }`)
+	if !errors.Is(err, ErrStepLimit) {
+		t.Errorf("got %v, want it to wrap ErrStepLimit", err)
+	}
+	if err := interpreter.Eval(`for (var n = 0; n < 100; n = n + 1) {}`); err != nil {
+		t.Errorf("the steps of earlier calls counted: %v", err)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	interpreter := New(WithStderr(io.Discard), WithContext(ctx))
+	err := interpreter.Eval(infiniteLoop)
+	if budgetErr := budgetError(t, err); !errors.Is(budgetErr, context.Canceled) {
+		t.Errorf("got %v, want it to wrap context.Canceled", budgetErr)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	interpreter = New(WithStderr(io.Discard), WithContext(ctx))
+	err = interpreter.Eval(infiniteLoop)
+	if budgetErr := budgetError(t, err); !errors.Is(budgetErr, context.DeadlineExceeded) {
+		t.Errorf("got %v, want it to wrap context.DeadlineExceeded", budgetErr)
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	interpreter := New(WithStderr(io.Discard), WithDeadline(time.Now().Add(10*time.Millisecond)))
+	if err := interpreter.Eval(`fun spin() { while (true) {} }`); err != nil {
+		t.Fatal(err)
+	}
+	_, err := interpreter.CallFunction("spin")
+	if budgetErr := budgetError(t, err); !errors.Is(budgetErr, context.DeadlineExceeded) {
+		t.Errorf("got %v, want it to wrap context.DeadlineExceeded", budgetErr)
+	}
+}
@@ -0,0 +1,222 @@
+package lox
+
+// https://craftinginterpreters.com/chunks-of-bytecode.html
+type opCode byte
+
+func (o opCode) String() string {
+
+	switch o {
+	case opConstant:
+		return "OP_CONSTANT"
+	case opNil:
+		return "OP_NIL"
+	case opTrue:
+		return "OP_TRUE"
+	case opFalse:
+		return "OP_FALSE"
+	case opPop:
+		return "OP_POP"
+
+	case opGetLocal:
+		return "OP_GET_LOCAL"
+	case opSetLocal:
+		return "OP_SET_LOCAL"
+	case opGetGlobal:
+		return "OP_GET_GLOBAL"
+	case opDefineGlobal:
+		return "OP_DEFINE_GLOBAL"
+	case opSetGlobal:
+		return "OP_SET_GLOBAL"
+	case opGetUpvalue:
+		return "OP_GET_UPVALUE"
+	case opSetUpvalue:
+		return "OP_SET_UPVALUE"
+	case opGetProperty:
+		return "OP_GET_PROPERTY"
+	case opSetProperty:
+		return "OP_SET_PROPERTY"
+	case opGetSuper:
+		return "OP_GET_SUPER"
+
+	case opEqual:
+		return "OP_EQUAL"
+	case opNotEqual:
+		return "OP_NOT_EQUAL"
+	case opGreater:
+		return "OP_GREATER"
+	case opGreaterEqual:
+		return "OP_GREATER_EQUAL"
+	case opLess:
+		return "OP_LESS"
+	case opLessEqual:
+		return "OP_LESS_EQUAL"
+	case opAdd:
+		return "OP_ADD"
+	case opSubtract:
+		return "OP_SUBTRACT"
+	case opMultiply:
+		return "OP_MULTIPLY"
+	case opDivide:
+		return "OP_DIVIDE"
+	case opNot:
+		return "OP_NOT"
+	case opNegate:
+		return "OP_NEGATE"
+
+	case opPrint:
+		return "OP_PRINT"
+	case opJump:
+		return "OP_JUMP"
+	case opJumpIfFalse:
+		return "OP_JUMP_IF_FALSE"
+	case opLoop:
+		return "OP_LOOP"
+	case opCall:
+		return "OP_CALL"
+	case opClosure:
+		return "OP_CLOSURE"
+	case opCloseUpvalue:
+		return "OP_CLOSE_UPVALUE"
+	case opReturn:
+		return "OP_RETURN"
+
+	case opClass:
+		return "OP_CLASS"
+	case opInherit:
+		return "OP_INHERIT"
+	case opMethod:
+		return "OP_METHOD"
+
+	case opList:
+		return "OP_LIST"
+	case opGetIndex:
+		return "OP_GET_INDEX"
+	case opSetIndex:
+		return "OP_SET_INDEX"
+	case opMap:
+		return "OP_MAP"
+
+	case opTry:
+		return "OP_TRY"
+	case opEndTry:
+		return "OP_END_TRY"
+	case opCatch:
+		return "OP_CATCH"
+	case opThrow:
+		return "OP_THROW"
+
+	case opInterpolate:
+		return "OP_INTERPOLATE"
+
+	case opImport:
+		return "OP_IMPORT"
+
+	default:
+		return "Unknown"
+	}
+}
+
+// Operands follow the opcode in the code stream. Constant indexes, jump
+// offsets (including the handler offset of OP_TRY), and the number of list
+// elements, map entries and interpolated string parts are two bytes (big
+// endian), local and upvalue slots and argument counts are a single byte.
+// OP_CLOSURE is followed by a pair of bytes (isLocal, index) for every upvalue
+// the function captures.
+const (
+	opConstant = opCode(iota)
+	opNil
+	opTrue
+	opFalse
+	opPop
+
+	// Variables and properties
+	opGetLocal
+	opSetLocal
+	opGetGlobal
+	opDefineGlobal
+	opSetGlobal
+	opGetUpvalue
+	opSetUpvalue
+	opGetProperty
+	opSetProperty
+	opGetSuper
+
+	// Operators. Unlike clox every comparison gets its own opcode,
+	// so runtime errors can point at the operator that was written.
+	opEqual
+	opNotEqual
+	opGreater
+	opGreaterEqual
+	opLess
+	opLessEqual
+	opAdd
+	opSubtract
+	opMultiply
+	opDivide
+	opNot
+	opNegate
+
+	// Control flow and functions
+	opPrint
+	opJump
+	opJumpIfFalse
+	opLoop
+	opCall
+	opClosure
+	opCloseUpvalue
+	opReturn
+
+	// Classes
+	opClass
+	opInherit
+	opMethod
+
+	// Lists and maps
+	opList
+	opGetIndex
+	opSetIndex
+	opMap
+
+	// Exceptions
+	opTry
+	opEndTry
+	opCatch
+	opThrow
+
+	// Strings
+	opInterpolate
+
+	// Modules
+	opImport
+)
+
+// chunk is a sequence of bytecode together with the constants it refers to.
+// positions holds the source position of every byte in code, and file
+// the name of the script they are in.
+type chunk struct {
+	code      []byte
+	positions []Position
+	constants []any
+	file      string
+}
+
+func (c *chunk) write(b byte, position Position) {
+	c.code = append(c.code, b)
+	c.positions = append(c.positions, position)
+}
+
+// addConstant adds the value to the constant pool and returns its index.
+// Numbers and strings that are already in the pool are reused.
+func (c *chunk) addConstant(value any) int {
+	switch value.(type) {
+	case float64, string:
+		for i, constant := range c.constants {
+			if constant == value {
+				return i
+			}
+		}
+	}
+
+	c.constants = append(c.constants, value)
+	return len(c.constants) - 1
+}
@@ -0,0 +1,853 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+)
+
+// The compiler lowers the resolved AST into bytecode for the VM. Unlike clox
+// it does not parse the source itself, it reuses the parser and walks the
+// same []statement the tree-walking Interpreter runs. Static errors such as
+// reading a local in its own initializer are left to the resolver.
+// https://craftinginterpreters.com/compiling-expressions.html
+
+const (
+	// Locals and upvalues are addressed with a single byte operand
+	maxLocals   = math.MaxUint8 + 1
+	maxUpvalues = math.MaxUint8 + 1
+	// Constant indexes and jumps use two byte operands
+	maxConstants = math.MaxUint16 + 1
+	maxJump      = math.MaxUint16
+)
+
+// https://craftinginterpreters.com/local-variables.html#representing-local-variables
+type local struct {
+	name string
+	// depth is -1 while the variable is declared, but not yet initialized
+	depth      int
+	isCaptured bool
+}
+
+// https://craftinginterpreters.com/closures.html#compiling-upvalues
+type upvalueRef struct {
+	index   byte
+	isLocal bool
+}
+
+// loop holds the jumps of the break and continue statements in a loop body,
+// to be patched once the loop is compiled
+type loop struct {
+	// scopeDepth is the scope depth outside the body, the locals declared
+	// deeper are popped before jumping out of the body
+	scopeDepth int
+	// tries is the number of try statements outside the loop, the ones
+	// inside are ended before jumping out of the body
+	tries         int
+	breakJumps    []int
+	continueJumps []int
+}
+
+// try is a try statement whose handler is active in the code being compiled
+type try struct {
+	// finally is run by the code that jumps out of the try, or nil
+	finally *blockStmt
+}
+
+type classCompiler struct {
+	enclosing     *classCompiler
+	hasSuperclass bool
+}
+
+// compiler holds the state for the function currently being compiled.
+// Nested function declarations get their own compiler, linked through enclosing.
+type compiler struct {
+	enclosing    *compiler
+	function     *objFunction
+	functionType functionType
+
+	locals     []local
+	upvalues   []upvalueRef
+	scopeDepth int
+	// loops holds the loops being compiled, the innermost one is the last,
+	// and tries the try statements
+	loops []*loop
+	tries []*try
+
+	currentClass *classCompiler
+	// file is the name of the script being compiled, and position where in it
+	// the last token we saw is. Both end up in the line table.
+	file     string
+	position Position
+
+	// diagnostics is shared by the compilers of all the nested functions
+	diagnostics *[]Diagnostic
+}
+
+func newCompiler(enclosing *compiler, functionType functionType, file string, name string) *compiler {
+	c := &compiler{
+		enclosing:    enclosing,
+		function:     newObjFunction(),
+		functionType: functionType,
+		file:         file,
+	}
+	c.function.name = name
+	c.function.chunk.file = file
+
+	c.position = Position{line: 1, column: 1}
+	c.diagnostics = &[]Diagnostic{}
+	if enclosing != nil {
+		c.currentClass = enclosing.currentClass
+		c.position = enclosing.position
+		c.diagnostics = enclosing.diagnostics
+	}
+
+	// Slot zero holds the function being called, or the receiver for methods
+	// https://craftinginterpreters.com/methods-and-initializers.html#this
+	slotZero := ""
+	if functionType == functionTypeMethod || functionType == functionTypeInitializer {
+		slotZero = "this"
+	}
+	c.locals = append(c.locals, local{name: slotZero, depth: 0})
+
+	return c
+}
+
+// compile compiles a whole program into the function for the top-level script.
+// The function can't be run if any errors are returned.
+func compile(file string, statements []statement) (*objFunction, []Diagnostic) {
+	c := newCompiler(nil, functionTypeNone, file, "")
+	for _, statement := range statements {
+		c.statement(statement)
+	}
+	return c.endCompiler(), *c.diagnostics
+}
+
+func (c *compiler) endCompiler() *objFunction {
+	c.emitReturn()
+	return c.function
+}
+
+func (c *compiler) chunk() *chunk {
+	return c.function.chunk
+}
+
+func (c *compiler) error(message string) {
+	span := Span{file: c.file, start: c.position, end: c.position}
+	*c.diagnostics = append(*c.diagnostics, newError(CODE_COMPILER_LIMIT, span, message))
+}
+
+func (c *compiler) statement(stmt statement) {
+	switch t := stmt.(type) {
+	case blockStmt:
+		c.beginScope()
+		for _, statement := range t.statements {
+			c.statement(statement)
+		}
+		c.endScope()
+	case classStmt:
+		c.classDeclaration(t)
+	case expressionStmt:
+		c.expression(t.expression)
+		c.emitOp(opPop)
+	case functionStmt:
+		c.functionDeclaration(t)
+	case ifStmt:
+		c.ifStatement(t)
+	case printStmt:
+		c.expression(t.expression)
+		c.emitOp(opPrint)
+	case returnStmt:
+		c.returnStatement(t)
+	case varStmt:
+		c.varDeclaration(t)
+	case importStmt:
+		c.importDeclaration(t)
+	case whileStmt:
+		c.whileStatement(t)
+	case throwStmt:
+		c.expression(t.value)
+		c.position = t.keyword.Position
+		c.emitOp(opThrow)
+	case tryStmt:
+		c.tryStatement(t)
+	case breakStmt:
+		loop := c.loops[len(c.loops)-1]
+		c.exitTries(loop.tries)
+		c.position = t.keyword.Position
+		c.discardLocals(loop.scopeDepth)
+		loop.breakJumps = append(loop.breakJumps, c.emitJump(opJump))
+	case continueStmt:
+		loop := c.loops[len(c.loops)-1]
+		c.exitTries(loop.tries)
+		c.position = t.keyword.Position
+		c.discardLocals(loop.scopeDepth)
+		loop.continueJumps = append(loop.continueJumps, c.emitJump(opJump))
+	default:
+		panic(fmt.Sprintf("compiling: unknown type %T: %v", stmt, t))
+	}
+}
+
+func (c *compiler) expression(expr expression) {
+	switch t := expr.(type) {
+	case *assignExpr:
+		c.expression(t.value)
+		c.position = t.name.Position
+		c.namedVariable(t.name.lexeme, true)
+	case *binaryExpr:
+		c.binary(t)
+	case *callExpr:
+		c.expression(t.callee)
+		for _, argument := range t.arguments {
+			c.expression(argument)
+		}
+		c.position = t.paren.Position
+		c.emitBytes(byte(opCall), byte(len(t.arguments)))
+	case *getExpr:
+		c.expression(t.object)
+		c.position = t.name.Position
+		c.emitConstantOp(opGetProperty, t.name.lexeme)
+	case *groupingExpr:
+		c.expression(t.expression)
+	case *indexExpr:
+		c.expression(t.object)
+		c.expression(t.index)
+		c.position = t.bracket.Position
+		c.emitOp(opGetIndex)
+	case *interpolationExpr:
+		for _, part := range t.parts {
+			c.expression(part)
+		}
+		c.position = t.start
+		if len(t.parts) > math.MaxUint16 {
+			c.error("Too many parts in interpolated string.")
+		}
+		c.emitOp(opInterpolate)
+		c.emitShort(len(t.parts))
+	case *listExpr:
+		for _, element := range t.elements {
+			c.expression(element)
+		}
+		c.position = t.start
+		if len(t.elements) > math.MaxUint16 {
+			c.error("Too many elements in list literal.")
+		}
+		c.emitOp(opList)
+		c.emitShort(len(t.elements))
+	case *literalExpr:
+		c.literal(t)
+	case *logicalExpr:
+		c.logical(t)
+	case *mapExpr:
+		for n := range t.keys {
+			c.expression(t.keys[n])
+			c.expression(t.values[n])
+		}
+		c.position = t.brace.Position
+		if len(t.keys) > math.MaxUint16 {
+			c.error("Too many entries in map literal.")
+		}
+		c.emitOp(opMap)
+		c.emitShort(len(t.keys))
+	case *setExpr:
+		c.expression(t.object)
+		c.expression(t.value)
+		c.position = t.name.Position
+		c.emitConstantOp(opSetProperty, t.name.lexeme)
+	case *setIndexExpr:
+		c.expression(t.object)
+		c.expression(t.index)
+		c.expression(t.value)
+		c.position = t.bracket.Position
+		c.emitOp(opSetIndex)
+	case *superExpr:
+		c.position = t.keyword.Position
+		c.namedVariable("this", false)
+		c.namedVariable("super", false)
+		c.position = t.method.Position
+		c.emitConstantOp(opGetSuper, t.method.lexeme)
+	case *thisExpr:
+		c.position = t.keyword.Position
+		c.namedVariable("this", false)
+	case *unaryExpr:
+		c.expression(t.right)
+		c.position = t.operator.Position
+		switch t.operator.tokenType {
+		case tokenBang:
+			c.emitOp(opNot)
+		case tokenMinus:
+			c.emitOp(opNegate)
+		default:
+			panic("compile unary: should never get here...")
+		}
+	case *variableExpr:
+		c.position = t.name.Position
+		c.namedVariable(t.name.lexeme, false)
+	default:
+		panic(fmt.Sprintf("compiling: unknown type %T: %v", expr, t))
+	}
+}
+
+func (c *compiler) literal(expr *literalExpr) {
+	c.position = expr.start
+	switch v := expr.value.(type) {
+	case nil:
+		c.emitOp(opNil)
+	case bool:
+		if v {
+			c.emitOp(opTrue)
+		} else {
+			c.emitOp(opFalse)
+		}
+	default:
+		c.emitConstantOp(opConstant, v)
+	}
+}
+
+func (c *compiler) binary(expr *binaryExpr) {
+	c.expression(expr.left)
+	c.expression(expr.right)
+
+	c.position = expr.operator.Position
+	switch expr.operator.tokenType {
+	case tokenBangEqual:
+		c.emitOp(opNotEqual)
+	case tokenEqualEqual:
+		c.emitOp(opEqual)
+	case tokenGreater:
+		c.emitOp(opGreater)
+	case tokenGreaterEqual:
+		c.emitOp(opGreaterEqual)
+	case tokenLess:
+		c.emitOp(opLess)
+	case tokenLessEqual:
+		c.emitOp(opLessEqual)
+	case tokenPlus:
+		c.emitOp(opAdd)
+	case tokenMinus:
+		c.emitOp(opSubtract)
+	case tokenStar:
+		c.emitOp(opMultiply)
+	case tokenSlash:
+		c.emitOp(opDivide)
+	default:
+		panic("compile binary: should never get here...")
+	}
+}
+
+// https://craftinginterpreters.com/jumping-back-and-forth.html#logical-operators
+func (c *compiler) logical(expr *logicalExpr) {
+	c.expression(expr.left)
+	c.position = expr.operator.Position
+
+	if expr.operator.tokenType == tokenOr {
+		elseJump := c.emitJump(opJumpIfFalse)
+		endJump := c.emitJump(opJump)
+
+		c.patchJump(elseJump)
+		c.emitOp(opPop)
+
+		c.expression(expr.right)
+		c.patchJump(endJump)
+		return
+	}
+
+	endJump := c.emitJump(opJumpIfFalse)
+	c.emitOp(opPop)
+	c.expression(expr.right)
+	c.patchJump(endJump)
+}
+
+func (c *compiler) varDeclaration(stmt varStmt) {
+	c.position = stmt.name.Position
+	c.declareVariable(stmt.name.lexeme)
+
+	if stmt.initializer != nil {
+		c.expression(stmt.initializer)
+	} else {
+		c.emitOp(opNil)
+	}
+
+	c.position = stmt.name.Position
+	c.defineVariable(stmt.name.lexeme)
+}
+
+// importDeclaration defines the name as the namespace of the module, which
+// the VM loads when OP_IMPORT runs
+func (c *compiler) importDeclaration(stmt importStmt) {
+	c.position = stmt.name.Position
+	c.declareVariable(stmt.name.lexeme)
+
+	c.position = stmt.keyword.Position
+	c.emitConstantOp(opImport, stmt.path.literal.(string))
+
+	c.position = stmt.name.Position
+	c.defineVariable(stmt.name.lexeme)
+}
+
+func (c *compiler) functionDeclaration(stmt functionStmt) {
+	c.position = stmt.name.Position
+	c.declareVariable(stmt.name.lexeme)
+	// A function may refer to itself, so it is initialized right away
+	c.markInitialized()
+	c.compileFunction(stmt, functionTypeFunction)
+	c.defineVariable(stmt.name.lexeme)
+}
+
+// compileFunction compiles the body of a function, method or initializer into its own
+// objFunction, and emits the instruction that wraps it in a closure at runtime
+// https://craftinginterpreters.com/closures.html#compiling-upvalues
+func (c *compiler) compileFunction(stmt functionStmt, functionType functionType) {
+	compiler := newCompiler(c, functionType, c.file, stmt.name.lexeme)
+	compiler.beginScope()
+
+	for _, param := range stmt.params {
+		compiler.function.arity++
+		compiler.position = param.Position
+		compiler.declareVariable(param.lexeme)
+		compiler.defineVariable(param.lexeme)
+	}
+
+	for _, statement := range stmt.body {
+		compiler.statement(statement)
+	}
+
+	// No need to end the scope, the frame is discarded when the function returns
+	function := compiler.endCompiler()
+
+	c.position = stmt.name.Position
+	c.emitConstantOp(opClosure, function)
+	for _, upvalue := range compiler.upvalues {
+		isLocal := byte(0)
+		if upvalue.isLocal {
+			isLocal = 1
+		}
+		c.emitBytes(isLocal, upvalue.index)
+	}
+}
+
+// https://craftinginterpreters.com/classes-and-instances.html#class-declarations
+func (c *compiler) classDeclaration(stmt classStmt) {
+	className := stmt.name.lexeme
+	c.position = stmt.name.Position
+
+	c.declareVariable(className)
+	c.emitConstantOp(opClass, className)
+	c.defineVariable(className)
+
+	classCompiler := &classCompiler{
+		enclosing: c.currentClass,
+	}
+	c.currentClass = classCompiler
+	defer func() {
+		c.currentClass = classCompiler.enclosing
+	}()
+
+	if stmt.superclass != nil {
+		c.expression(stmt.superclass)
+
+		// The superclass lives in a local variable called "super",
+		// which the methods capture as an upvalue
+		// https://craftinginterpreters.com/superclasses.html#a-new-class-scope
+		c.beginScope()
+		c.addLocal("super")
+		c.defineVariable("super")
+
+		c.namedVariable(className, false)
+		c.position = stmt.superclass.name.Position
+		c.emitConstantOp(opInherit, stmt.superclass.name.lexeme)
+		classCompiler.hasSuperclass = true
+	}
+
+	// Load the class so the methods can be attached to it
+	c.namedVariable(className, false)
+	for _, method := range stmt.methods {
+		functionType := functionTypeMethod
+		if method.name.lexeme == "init" {
+			functionType = functionTypeInitializer
+		}
+		c.compileFunction(method, functionType)
+		c.emitConstantOp(opMethod, method.name.lexeme)
+	}
+	c.emitOp(opPop)
+
+	if classCompiler.hasSuperclass {
+		c.endScope()
+	}
+}
+
+// https://craftinginterpreters.com/jumping-back-and-forth.html#if-statements
+func (c *compiler) ifStatement(stmt ifStmt) {
+	c.expression(stmt.condition)
+
+	thenJump := c.emitJump(opJumpIfFalse)
+	c.emitOp(opPop)
+	c.statement(stmt.thenBranch)
+
+	elseJump := c.emitJump(opJump)
+
+	c.patchJump(thenJump)
+	c.emitOp(opPop)
+
+	if stmt.elseBranch != nil {
+		c.statement(stmt.elseBranch)
+	}
+	c.patchJump(elseJump)
+}
+
+func (c *compiler) whileStatement(stmt whileStmt) {
+	loopStart := len(c.chunk().code)
+	c.expression(stmt.condition)
+
+	exitJump := c.emitJump(opJumpIfFalse)
+	c.emitOp(opPop)
+
+	// The resolver made sure break and continue are only used in loops
+	loop := &loop{scopeDepth: c.scopeDepth, tries: len(c.tries)}
+	c.loops = append(c.loops, loop)
+	c.statement(stmt.body)
+	c.loops = c.loops[:len(c.loops)-1]
+
+	for _, jump := range loop.continueJumps {
+		c.patchJump(jump)
+	}
+	if stmt.increment != nil {
+		c.expression(stmt.increment)
+		c.emitOp(opPop)
+	}
+	c.emitLoop(loopStart)
+
+	c.patchJump(exitJump)
+	c.emitOp(opPop)
+
+	// The condition was already popped when the body was entered
+	for _, jump := range loop.breakJumps {
+		c.patchJump(jump)
+	}
+}
+
+func (c *compiler) returnStatement(stmt returnStmt) {
+	c.position = stmt.keyword.Position
+	if stmt.value == nil {
+		c.emitImplicitReturnValue()
+	} else {
+		c.expression(stmt.value)
+	}
+
+	if len(c.tries) > 0 {
+		// The finally clauses run with the value to return on the stack,
+		// as a local without a name
+		c.beginScope()
+		c.addLocal("")
+		c.markInitialized()
+		c.exitTries(0)
+		c.forgetScope()
+	}
+	c.position = stmt.keyword.Position
+	c.emitOp(opReturn)
+}
+
+// tryStatement compiles a try statement to:
+//
+//	OP_TRY handler
+//	body
+//	OP_END_TRY
+//	OP_JUMP done
+//
+//	handler:            the error is on top of the stack
+//	OP_TRY rethrow      only with a finally clause
+//	OP_CATCH            the error becomes the catch variable
+//	catch body
+//	OP_END_TRY          only with a finally clause
+//
+//	done:
+//	finally body
+//	OP_JUMP end
+//
+//	rethrow:            the error is on top of the stack
+//	finally body
+//	OP_THROW
+//	end:
+//
+// Without a catch clause, the handler is the rethrow code. Jumping out of the
+// body or catch clause with return, break or continue ends the handlers, and
+// runs a copy of the finally body, see exitTries.
+func (c *compiler) tryStatement(stmt tryStmt) {
+	c.position = stmt.keyword.Position
+	handler := c.emitJump(opTry)
+	c.tries = append(c.tries, &try{finally: stmt.finally})
+	c.statement(stmt.body)
+	c.tries = c.tries[:len(c.tries)-1]
+	c.emitOp(opEndTry)
+	done := c.emitJump(opJump)
+
+	rethrow := handler
+	if stmt.catch != nil {
+		c.patchJump(handler)
+		if stmt.finally != nil {
+			rethrow = c.emitJump(opTry)
+			c.tries = append(c.tries, &try{finally: stmt.finally})
+		}
+		c.position = stmt.catch.name.Position
+		c.emitOp(opCatch)
+
+		c.beginScope()
+		c.addLocal(stmt.catch.name.lexeme)
+		c.markInitialized()
+		for _, statement := range stmt.catch.body {
+			c.statement(statement)
+		}
+		if stmt.finally != nil {
+			c.tries = c.tries[:len(c.tries)-1]
+			c.emitOp(opEndTry)
+		}
+		c.endScope()
+	}
+
+	c.patchJump(done)
+	if stmt.finally == nil {
+		return
+	}
+	c.statement(*stmt.finally)
+	end := c.emitJump(opJump)
+
+	c.patchJump(rethrow)
+	c.beginScope()
+	if stmt.catch != nil {
+		// The catch variable is still on the stack, below the error
+		c.addLocal("")
+		c.markInitialized()
+	}
+	c.addLocal("")
+	c.markInitialized()
+	c.statement(*stmt.finally)
+	c.emitOp(opThrow)
+	c.forgetScope()
+
+	c.patchJump(end)
+}
+
+// exitTries emits the code for jumping out of the try statements being
+// compiled, down to the first count of them: their handlers are ended and
+// their finally bodies run, innermost first.
+func (c *compiler) exitTries(count int) {
+	tries := c.tries
+	defer func() {
+		c.tries = tries
+	}()
+
+	for n := len(tries) - 1; n >= count; n-- {
+		c.emitOp(opEndTry)
+		if tries[n].finally != nil {
+			// Jumping out of the finally body doesn't run it again
+			c.tries = tries[:n]
+			c.statement(*tries[n].finally)
+		}
+	}
+}
+
+// discardLocals pops the locals deeper than depth off the stack, for a jump
+// out of their scopes. They stay in c.locals, as the scopes are still being
+// compiled.
+func (c *compiler) discardLocals(depth int) {
+	for n := len(c.locals) - 1; n >= 0 && c.locals[n].depth > depth; n-- {
+		if c.locals[n].isCaptured {
+			c.emitOp(opCloseUpvalue)
+		} else {
+			c.emitOp(opPop)
+		}
+	}
+}
+
+// forgetScope ends the scope without popping its locals, for when the code
+// ends with a return or throw that leaves the stack behind anyway
+func (c *compiler) forgetScope() {
+	c.scopeDepth--
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.scopeDepth {
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+// https://craftinginterpreters.com/local-variables.html#block-statements
+func (c *compiler) beginScope() {
+	c.scopeDepth++
+}
+
+func (c *compiler) endScope() {
+	c.scopeDepth--
+
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.scopeDepth {
+		if c.locals[len(c.locals)-1].isCaptured {
+			c.emitOp(opCloseUpvalue)
+		} else {
+			c.emitOp(opPop)
+		}
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+// declareVariable records a local variable. Globals are late bound,
+// so there is nothing to declare for them.
+func (c *compiler) declareVariable(name string) {
+	if c.scopeDepth == 0 {
+		return
+	}
+	c.addLocal(name)
+}
+
+func (c *compiler) addLocal(name string) {
+	if len(c.locals) == maxLocals {
+		c.error("Too many local variables in function.")
+		return
+	}
+	c.locals = append(c.locals, local{name: name, depth: -1})
+}
+
+func (c *compiler) markInitialized() {
+	if c.scopeDepth == 0 {
+		return
+	}
+	c.locals[len(c.locals)-1].depth = c.scopeDepth
+}
+
+// defineVariable emits the code that stores the value on top of the
+// stack in the variable. Locals already live in their stack slot.
+func (c *compiler) defineVariable(name string) {
+	if c.scopeDepth > 0 {
+		c.markInitialized()
+		return
+	}
+	c.emitConstantOp(opDefineGlobal, name)
+}
+
+func (c *compiler) namedVariable(name string, assign bool) {
+	if arg := c.resolveLocal(name); arg != -1 {
+		c.emitBytes(byte(trn(assign, opSetLocal, opGetLocal)), byte(arg))
+	} else if arg := c.resolveUpvalue(name); arg != -1 {
+		c.emitBytes(byte(trn(assign, opSetUpvalue, opGetUpvalue)), byte(arg))
+	} else {
+		c.emitConstantOp(trn(assign, opSetGlobal, opGetGlobal), name)
+	}
+}
+
+// https://craftinginterpreters.com/local-variables.html#using-locals
+func (c *compiler) resolveLocal(name string) int {
+	for i := len(c.locals) - 1; i >= 0; i-- {
+		if c.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveUpvalue looks for the variable in the enclosing functions, and
+// threads an upvalue through every function in between
+// https://craftinginterpreters.com/closures.html#flattening-upvalues
+func (c *compiler) resolveUpvalue(name string) int {
+	if c.enclosing == nil {
+		return -1
+	}
+
+	if local := c.enclosing.resolveLocal(name); local != -1 {
+		c.enclosing.locals[local].isCaptured = true
+		return c.addUpvalue(byte(local), true)
+	}
+
+	if upvalue := c.enclosing.resolveUpvalue(name); upvalue != -1 {
+		return c.addUpvalue(byte(upvalue), false)
+	}
+
+	return -1
+}
+
+func (c *compiler) addUpvalue(index byte, isLocal bool) int {
+	for i, upvalue := range c.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return i
+		}
+	}
+
+	if len(c.upvalues) == maxUpvalues {
+		c.error("Too many closure variables in function.")
+		return 0
+	}
+
+	c.upvalues = append(c.upvalues, upvalueRef{index: index, isLocal: isLocal})
+	c.function.upvalueCount = len(c.upvalues)
+	return len(c.upvalues) - 1
+}
+
+func (c *compiler) emitByte(b byte) {
+	c.chunk().write(b, c.position)
+}
+
+func (c *compiler) emitBytes(b1, b2 byte) {
+	c.emitByte(b1)
+	c.emitByte(b2)
+}
+
+func (c *compiler) emitOp(op opCode) {
+	c.emitByte(byte(op))
+}
+
+func (c *compiler) emitShort(value int) {
+	c.emitBytes(byte(value>>8), byte(value))
+}
+
+func (c *compiler) emitReturn() {
+	c.emitImplicitReturnValue()
+	c.emitOp(opReturn)
+}
+
+func (c *compiler) emitImplicitReturnValue() {
+	// An initializer implicitly returns "this", which lives in slot zero
+	if c.functionType == functionTypeInitializer {
+		c.emitBytes(byte(opGetLocal), 0)
+	} else {
+		c.emitOp(opNil)
+	}
+}
+
+func (c *compiler) makeConstant(value any) int {
+	constant := c.chunk().addConstant(value)
+	if constant >= maxConstants {
+		c.error("Too many constants in one chunk.")
+		return 0
+	}
+	return constant
+}
+
+func (c *compiler) emitConstantOp(op opCode, value any) {
+	c.emitOp(op)
+	c.emitShort(c.makeConstant(value))
+}
+
+// emitJump emits a jump with a placeholder offset, and returns the
+// position of the offset so it can be patched later
+// https://craftinginterpreters.com/jumping-back-and-forth.html#if-statements
+func (c *compiler) emitJump(op opCode) int {
+	c.emitOp(op)
+	c.emitShort(0xffff)
+	return len(c.chunk().code) - 2
+}
+
+func (c *compiler) patchJump(offset int) {
+	// -2 to adjust for the jump offset itself
+	jump := len(c.chunk().code) - offset - 2
+	if jump > maxJump {
+		c.error("Too much code to jump over.")
+	}
+
+	c.chunk().code[offset] = byte(jump >> 8)
+	c.chunk().code[offset+1] = byte(jump)
+}
+
+func (c *compiler) emitLoop(loopStart int) {
+	c.emitOp(opLoop)
+
+	// +2 to adjust for the loop offset itself
+	offset := len(c.chunk().code) - loopStart + 2
+	if offset > maxJump {
+		c.error("Loop body too large.")
+	}
+	c.emitShort(offset)
+}
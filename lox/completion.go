@@ -0,0 +1,44 @@
+package lox
+
+// completionType describes how a statement finished executing.
+// The book uses Java exceptions to unwind the stack for return
+// statements, here every statement reports how it completed instead,
+// and the enclosing blocks, loops and functions decide what to do with it.
+// Thrown values travel as RuntimeErrors, see throwError.
+type completionType int
+
+const (
+	completionNormal = completionType(iota)
+	completionReturn
+	completionBreak
+	completionContinue
+)
+
+func (c completionType) String() string {
+	switch c {
+	case completionNormal:
+		return "NORMAL"
+	case completionReturn:
+		return "RETURN"
+	case completionBreak:
+		return "BREAK"
+	case completionContinue:
+		return "CONTINUE"
+	default:
+		return "Unknown"
+	}
+}
+
+type completion struct {
+	completionType completionType
+	// value is the returned value, if any
+	value any
+}
+
+var normalCompletion = completion{completionType: completionNormal}
+
+// abrupt reports whether the completion should stop execution
+// of the statements following it
+func (c completion) abrupt() bool {
+	return c.completionType != completionNormal
+}
@@ -0,0 +1,122 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+)
+
+// https://craftinginterpreters.com/chunks-of-bytecode.html#disassembling-chunks
+
+// disassembleFunction prints the chunk of the function, followed by the
+// chunks of every function declared inside it
+func disassembleFunction(w io.Writer, function *objFunction) {
+	disassembleChunk(w, function.chunk, function.String())
+
+	for _, constant := range function.chunk.constants {
+		if nested, ok := constant.(*objFunction); ok {
+			fmt.Fprintln(w)
+			disassembleFunction(w, nested)
+		}
+	}
+}
+
+func disassembleChunk(w io.Writer, chunk *chunk, name string) {
+	fmt.Fprintf(w, "== %s ==\n", name)
+
+	for offset := 0; offset < len(chunk.code); {
+		offset = disassembleInstruction(w, chunk, offset)
+	}
+}
+
+// disassembleInstruction prints the instruction at the offset and
+// returns the offset of the next instruction
+func disassembleInstruction(w io.Writer, chunk *chunk, offset int) int {
+	fmt.Fprintf(w, "%04d ", offset)
+	if offset > 0 && chunk.positions[offset].line == chunk.positions[offset-1].line {
+		fmt.Fprintf(w, "   | ")
+	} else {
+		fmt.Fprintf(w, "%4d ", chunk.positions[offset].line)
+	}
+
+	op := opCode(chunk.code[offset])
+	switch op {
+	case opConstant, opGetGlobal, opDefineGlobal, opSetGlobal,
+		opGetProperty, opSetProperty, opGetSuper,
+		opClass, opInherit, opMethod, opImport:
+		return constantInstruction(w, op, chunk, offset)
+	case opGetLocal, opSetLocal, opGetUpvalue, opSetUpvalue, opCall:
+		return byteInstruction(w, op, chunk, offset)
+	case opJump, opJumpIfFalse, opTry:
+		return jumpInstruction(w, op, 1, chunk, offset)
+	case opLoop:
+		return jumpInstruction(w, op, -1, chunk, offset)
+	case opClosure:
+		return closureInstruction(w, chunk, offset)
+	case opList, opMap, opInterpolate:
+		return shortInstruction(w, op, chunk, offset)
+	case opNil, opTrue, opFalse, opPop,
+		opEqual, opNotEqual, opGreater, opGreaterEqual, opLess, opLessEqual,
+		opAdd, opSubtract, opMultiply, opDivide, opNot, opNegate,
+		opPrint, opCloseUpvalue, opReturn,
+		opGetIndex, opSetIndex,
+		opEndTry, opCatch, opThrow:
+		return simpleInstruction(w, op, offset)
+	default:
+		fmt.Fprintf(w, "Unknown opcode %d\n", op)
+		return offset + 1
+	}
+}
+
+func simpleInstruction(w io.Writer, op opCode, offset int) int {
+	fmt.Fprintf(w, "%s\n", op)
+	return offset + 1
+}
+
+func byteInstruction(w io.Writer, op opCode, chunk *chunk, offset int) int {
+	slot := chunk.code[offset+1]
+	fmt.Fprintf(w, "%-16s %4d\n", op, slot)
+	return offset + 2
+}
+
+func readShortAt(chunk *chunk, offset int) int {
+	return int(chunk.code[offset])<<8 | int(chunk.code[offset+1])
+}
+
+func constantInstruction(w io.Writer, op opCode, chunk *chunk, offset int) int {
+	constant := readShortAt(chunk, offset+1)
+	fmt.Fprintf(w, "%-16s %4d '%s'\n", op, constant, stringify(chunk.constants[constant]))
+	return offset + 3
+}
+
+// shortInstruction prints an instruction with a two byte operand that is not a constant
+func shortInstruction(w io.Writer, op opCode, chunk *chunk, offset int) int {
+	fmt.Fprintf(w, "%-16s %4d\n", op, readShortAt(chunk, offset+1))
+	return offset + 3
+}
+
+// jumpInstruction prints the jump together with the offset it jumps to
+func jumpInstruction(w io.Writer, op opCode, sign int, chunk *chunk, offset int) int {
+	jump := readShortAt(chunk, offset+1)
+	fmt.Fprintf(w, "%-16s %4d -> %d\n", op, offset, offset+3+sign*jump)
+	return offset + 3
+}
+
+// closureInstruction prints the function, and one line for every upvalue
+// it captures, either from a local slot or an upvalue of the enclosing function
+func closureInstruction(w io.Writer, chunk *chunk, offset int) int {
+	constant := readShortAt(chunk, offset+1)
+	offset += 3
+
+	function := chunk.constants[constant].(*objFunction)
+	fmt.Fprintf(w, "%-16s %4d %s\n", opClosure, constant, function)
+
+	for i := 0; i < function.upvalueCount; i++ {
+		isLocal := chunk.code[offset]
+		index := chunk.code[offset+1]
+		fmt.Fprintf(w, "%04d    |                     %s %d\n",
+			offset, trn(isLocal == 1, "local", "upvalue"), index)
+		offset += 2
+	}
+
+	return offset
+}
@@ -0,0 +1,305 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic is an error together with the part of the source it is about.
+// Scanner, parser, resolver and runtime errors are all reported as
+// diagnostics, and printed by a renderer in the style of rustc:
+//
+//	error[E0201]: Already a variable with this name in this scope.
+//	 --> script.lox:3:7
+//	  |
+//	3 |   var a = 2;
+//	  |       ^
+//	note: previous declaration here
+//	 --> script.lox:2:7
+//	  |
+//	2 |   var a = 1;
+//	  |       -
+type Diagnostic struct {
+	severity Severity
+	// code identifies the kind of problem, so tools don't have to match on
+	// the message. It is empty for runtime errors.
+	code    string
+	span    Span
+	message string
+	notes   []note
+	// trace is the stack trace of a runtime error, innermost call first
+	trace []StackFrame
+}
+
+// Severity returns whether the diagnostic is an error or a warning
+func (d Diagnostic) Severity() Severity {
+	return d.severity
+}
+
+// Code returns the code of the diagnostic, like "E0201", see the CODE_ constants
+func (d Diagnostic) Code() string {
+	return d.code
+}
+
+// Span returns the part of the source the diagnostic is about
+func (d Diagnostic) Span() Span {
+	return d.span
+}
+
+// Message returns the message of the diagnostic, without its location
+func (d Diagnostic) Message() string {
+	return d.message
+}
+
+// newError returns an error diagnostic
+func newError(code string, span Span, message string, notes ...note) Diagnostic {
+	return Diagnostic{
+		severity: SEVERITY_ERROR,
+		code:     code,
+		span:     span,
+		message:  message,
+		notes:    notes,
+	}
+}
+
+// hasErrors reports whether any of the diagnostics is an error, and not just a warning
+func hasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.severity == SEVERITY_ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+type Severity int
+
+const (
+	// Errors stop the script from running
+	SEVERITY_ERROR = Severity(iota)
+	SEVERITY_WARNING
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SEVERITY_ERROR:
+		return "error"
+	case SEVERITY_WARNING:
+		return "warning"
+	default:
+		panic(fmt.Sprintf("unknown severity %d", s))
+	}
+}
+
+// The diagnostic codes. Scanner errors start at E0001, parser errors at
+// E0100, resolver errors at E0200 and bytecode compiler errors at E0300.
+const (
+	CODE_UNEXPECTED_CHARACTER     = "E0001"
+	CODE_UNTERMINATED_STRING      = "E0002"
+	CODE_INVALID_ESCAPE           = "E0003"
+	CODE_SYNTAX                   = "E0100"
+	CODE_INVALID_ASSIGNMENT       = "E0101"
+	CODE_TOO_MANY_ARGUMENTS       = "E0102"
+	CODE_OWN_INITIALIZER          = "E0200"
+	CODE_REDECLARED               = "E0201"
+	CODE_TOP_LEVEL_RETURN         = "E0202"
+	CODE_INITIALIZER_RETURN       = "E0203"
+	CODE_THIS_OUTSIDE_CLASS       = "E0204"
+	CODE_SUPER_OUTSIDE_CLASS      = "E0205"
+	CODE_SUPER_WITHOUT_SUPERCLASS = "E0206"
+	CODE_INHERIT_FROM_SELF        = "E0207"
+	CODE_OUTSIDE_LOOP             = "E0208"
+	CODE_COMPILER_LIMIT           = "E0300"
+)
+
+// note points at another part of the source that helps explaining a diagnostic
+type note struct {
+	span    Span
+	message string
+}
+
+// ANSI escape codes used when printing in color
+const (
+	ansiReset      = "\x1b[0m"
+	ansiBold       = "\x1b[1m"
+	ansiBoldRed    = "\x1b[1;31m"
+	ansiBoldYellow = "\x1b[1;33m"
+	ansiBoldBlue   = "\x1b[1;34m"
+	ansiBoldCyan   = "\x1b[1;36m"
+)
+
+// renderer prints diagnostics with the source lines they point at.
+// The source of every file is registered with addSource when it is scanned,
+// files that were not registered (e.g. the script a .loxc file was compiled
+// from) are read from disk if they are still there.
+type renderer struct {
+	color   bool
+	sources map[string][]string
+}
+
+func newRenderer() *renderer {
+	return &renderer{
+		sources: make(map[string][]string),
+	}
+}
+
+func (r *renderer) addSource(file string, source string) {
+	r.sources[file] = strings.Split(source, "\n")
+}
+
+// sourceLine returns the line of the file, without the line ending
+func (r *renderer) sourceLine(file string, line int) (string, bool) {
+	lines, ok := r.sources[file]
+	if !ok {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", false
+		}
+		r.addSource(file, string(data))
+		lines = r.sources[file]
+	}
+
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	return strings.TrimSuffix(lines[line-1], "\r"), true
+}
+
+func (r *renderer) paint(style string, s string) string {
+	if !r.color {
+		return s
+	}
+	return style + s + ansiReset
+}
+
+func (r *renderer) render(w io.Writer, d Diagnostic) {
+	heading := d.severity.String()
+	if d.code != "" {
+		heading += "[" + d.code + "]"
+	}
+	style := ansiBoldRed
+	if d.severity == SEVERITY_WARNING {
+		style = ansiBoldYellow
+	}
+	fmt.Fprintf(w, "%s%s\n", r.paint(style, heading), r.paint(ansiBold, ": "+d.message))
+	r.snippet(w, d.span, "^", style)
+
+	for _, note := range d.notes {
+		fmt.Fprintf(w, "%s%s\n", r.paint(ansiBoldCyan, "note"), r.paint(ansiBold, ": "+note.message))
+		r.snippet(w, note.span, "-", ansiBoldCyan)
+	}
+
+	if len(d.trace) > 0 {
+		fmt.Fprintln(w, r.paint(ansiBold, "stack trace (most recent call first):"))
+		r.stackTrace(w, d.trace)
+	}
+	fmt.Fprintln(w)
+}
+
+// report prints the diagnostics, and reports whether any of them are errors
+func (r *renderer) report(w io.Writer, diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		r.render(w, diagnostic)
+	}
+	return hasErrors(diagnostics)
+}
+
+// runtimeError prints the RuntimeError, or BudgetError, returned by one of the backends
+func (r *renderer) runtimeError(w io.Writer, err error) {
+	var budgetErr BudgetError
+	if errors.As(err, &budgetErr) {
+		r.render(w, Diagnostic{
+			severity: SEVERITY_ERROR,
+			span:     budgetErr.span,
+			message:  fmt.Sprintf("Execution stopped: %v.", budgetErr.err),
+		})
+		return
+	}
+
+	var runtimeErr RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		panic(err)
+	}
+	r.render(w, Diagnostic{
+		severity: SEVERITY_ERROR,
+		span:     runtimeErr.Span(),
+		message:  runtimeErr.msg,
+		trace:    runtimeErr.trace,
+	})
+}
+
+// maxRepeatedFrames is how many times the same frame is printed in a row,
+// before the rest are summed up. Deep recursion would print thousands otherwise.
+const maxRepeatedFrames = 3
+
+func (r *renderer) stackTrace(w io.Writer, trace []StackFrame) {
+	for i := 0; i < len(trace); {
+		// Find the run of frames that are the same as this one
+		j := i + 1
+		for j < len(trace) && trace[j].function == trace[i].function &&
+			trace[j].callSite.Position == trace[i].callSite.Position {
+			j++
+		}
+
+		for k := i; k < j && k < i+maxRepeatedFrames; k++ {
+			fmt.Fprintf(w, "  %v\n", trace[k])
+		}
+		if repeated := j - i - maxRepeatedFrames; repeated > 0 {
+			fmt.Fprintf(w, "  [previous frame repeated %d more times]\n", repeated)
+		}
+		i = j
+	}
+}
+
+// snippet prints where the span is, and the line it starts on with the span
+// underlined. Spans over several lines are underlined to the end of the first.
+func (r *renderer) snippet(w io.Writer, span Span, underline string, style string) {
+	gutter := strings.Repeat(" ", len(strconv.Itoa(span.start.line)))
+	fmt.Fprintf(w, "%s%s %v\n", gutter, r.paint(ansiBoldBlue, "-->"), span)
+
+	text, ok := r.sourceLine(span.file, span.start.line)
+	if !ok {
+		return
+	}
+
+	// Columns count runes
+	runes := []rune(text)
+	start := clamp(span.start.column-1, 0, len(runes))
+	end := len(runes)
+	if span.end.line == span.start.line {
+		end = clamp(span.end.column-1, start, len(runes))
+	}
+
+	// Keep the tabs in front of the span, so the underline lines up with the text
+	var padding strings.Builder
+	for _, c := range runes[:start] {
+		if c == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+	width := end - start
+	if width == 0 {
+		// Empty spans, like the end of the file, still get a caret
+		width = 1
+	}
+
+	fmt.Fprintf(w, "%s %s\n", gutter, r.paint(ansiBoldBlue, "|"))
+	fmt.Fprintf(w, "%s %s %s\n", r.paint(ansiBoldBlue, strconv.Itoa(span.start.line)), r.paint(ansiBoldBlue, "|"), text)
+	fmt.Fprintf(w, "%s %s %s%s\n", gutter, r.paint(ansiBoldBlue, "|"), padding.String(), r.paint(style, strings.Repeat(underline, width)))
+}
+
+func clamp(n, low, high int) int {
+	if n < low {
+		return low
+	}
+	if n > high {
+		return high
+	}
+	return n
+}
@@ -0,0 +1,69 @@
+package lox
+
+import "fmt"
+
+type environment struct {
+	enclosing *environment // https://craftinginterpreters.com/statements-and-state.html#nesting-and-shadowing
+	values    map[string]any
+}
+
+func newEnvironment(enclosing *environment) *environment {
+	return &environment{
+		enclosing: enclosing,
+		values:    make(map[string]any),
+	}
+}
+
+func (e *environment) define(name string, value any) {
+	e.values[name] = value
+}
+
+func (e *environment) get(name token) (any, error) {
+	val, ok := e.values[name.lexeme]
+	if ok {
+		return val, nil
+	}
+
+	if e.enclosing != nil {
+		return e.enclosing.get(name)
+	}
+
+	return nil, RuntimeError{
+		token: name,
+		msg:   fmt.Sprintf("Undefined variable '%s'.", name.lexeme),
+	}
+}
+
+func (e *environment) assign(name token, value any) error {
+	_, ok := e.values[name.lexeme]
+	if ok {
+		e.values[name.lexeme] = value
+		return nil
+	}
+
+	if e.enclosing != nil {
+		return e.enclosing.assign(name, value)
+	}
+
+	return RuntimeError{
+		token: name,
+		msg:   fmt.Sprintf("Tried to assign undefined variable: Undefined variable '%s'.", name.lexeme),
+	}
+}
+
+// https://craftinginterpreters.com/resolving-and-binding.html#interpreting-resolved-variables
+func (e *environment) ancestor(distance int) *environment {
+	environment := e
+	for i := 0; i < distance; i++ {
+		environment = environment.enclosing
+	}
+	return environment
+}
+
+func (e *environment) getAt(distance int, name string) any {
+	return e.ancestor(distance).values[name]
+}
+
+func (e *environment) assignAt(distance int, name token, value any) {
+	e.ancestor(distance).values[name.lexeme] = value
+}
@@ -0,0 +1,199 @@
+package lox
+
+type expression interface {
+	Eval() expression
+	span() Span
+}
+
+// ASSIGN
+type assignExpr struct {
+	Span
+	name  token
+	value expression
+}
+
+func (b assignExpr) Eval() expression {
+	panic("assign not implemented yet")
+}
+
+// BINARY
+type binaryExpr struct {
+	Span
+	left     expression
+	operator token
+	right    expression
+}
+
+func (b binaryExpr) Eval() expression {
+	panic("binary eval not implemented yet")
+}
+
+// CALL
+type callExpr struct {
+	Span
+	callee    expression
+	paren     token
+	arguments []expression
+}
+
+func (b callExpr) Eval() expression {
+	panic("call eval not implemented yet")
+}
+
+// GET
+type getExpr struct {
+	Span
+	object expression
+	name   token
+}
+
+func (b getExpr) Eval() expression {
+	panic("get eval not implemented yet")
+}
+
+// GROUPING (
+type groupingExpr struct {
+	Span
+	expression expression
+}
+
+func (b groupingExpr) Eval() expression {
+	panic("grouping eval not implemented yet")
+}
+
+// INDEX [
+type indexExpr struct {
+	Span
+	object expression
+	// bracket is the closing bracket, where errors are reported
+	bracket token
+	index   expression
+}
+
+func (b indexExpr) Eval() expression {
+	panic("index eval not implemented yet")
+}
+
+// INTERPOLATION "a ${b} c"
+type interpolationExpr struct {
+	Span
+	// parts holds the expressions in the string, and Literals for the text
+	// between them, in order
+	parts []expression
+}
+
+func (b interpolationExpr) Eval() expression {
+	panic("interpolation eval not implemented yet")
+}
+
+// LIST
+type listExpr struct {
+	Span
+	elements []expression
+}
+
+func (b listExpr) Eval() expression {
+	panic("list eval not implemented yet")
+}
+
+// LITERAL
+type literalExpr struct {
+	Span
+	value any
+}
+
+func (b literalExpr) Eval() expression {
+	panic("literal eval not implemented yet")
+}
+
+// LOGICAL
+type logicalExpr struct {
+	Span
+	left     expression
+	operator token
+	right    expression
+}
+
+func (b logicalExpr) Eval() expression {
+	panic("logical eval not implemented yet")
+}
+
+// MAP {
+type mapExpr struct {
+	Span
+	// brace is the opening brace, where invalid keys are reported
+	brace  token
+	keys   []expression
+	values []expression
+}
+
+func (b mapExpr) Eval() expression {
+	panic("map eval not implemented yet")
+}
+
+// SET
+type setExpr struct {
+	Span
+	object expression
+	name   token
+	value  expression
+}
+
+func (b setExpr) Eval() expression {
+	panic("set eval not implemented yet")
+}
+
+// SET INDEX
+type setIndexExpr struct {
+	Span
+	object  expression
+	bracket token
+	index   expression
+	value   expression
+}
+
+func (b setIndexExpr) Eval() expression {
+	panic("set index eval not implemented yet")
+}
+
+// SUPER
+type superExpr struct {
+	Span
+	keyword token
+	method  token
+}
+
+func (b superExpr) Eval() expression {
+	panic("super eval not implemented yet")
+}
+
+// THIS
+type thisExpr struct {
+	Span
+	keyword token
+}
+
+func (b thisExpr) Eval() expression {
+	panic("this eval not implemented yet")
+}
+
+// UNARY
+type unaryExpr struct {
+	Span
+	operator token
+	right    expression
+}
+
+func (b unaryExpr) Eval() expression {
+	panic("logical eval not implemented yet")
+}
+
+// VARIABLE
+type variableExpr struct {
+	Span
+	name token
+}
+
+func (b variableExpr) Eval() expression {
+	panic("should not be called")
+}
@@ -0,0 +1,182 @@
+package lox
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// GoObject is a Go struct or map used as an object in Lox. Exported struct
+// fields and map entries with string keys are read and written with property
+// access, like the fields of a LoxInstance, and exported methods can be
+// called like Lox methods. Structs passed by value can only be read, pass a
+// pointer to let scripts write the fields.
+type GoObject struct {
+	value reflect.Value
+	// allowed holds the names of the only fields, entries and methods scripts
+	// can use, and is nil if they can use all of them. It also applies to the
+	// objects reached through this one.
+	allowed map[string]bool
+}
+
+// NewGoObject wraps the struct, pointer to a struct, or map with string keys.
+// If names are given, scripts can only use the fields, map entries and
+// methods with those names, which is how the host hides everything else.
+func NewGoObject(value any, allowed ...string) (*GoObject, error) {
+	v := reflect.ValueOf(value)
+	if !isGoObject(v) {
+		return nil, fmt.Errorf("can't use %T as a Lox object, it must be a struct, a pointer to one or a map with string keys", value)
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Map) && v.IsNil() {
+		// A nil map would panic as soon as a script set an entry
+		return nil, fmt.Errorf("can't use a nil %T as a Lox object", value)
+	}
+
+	object := &GoObject{value: v}
+	if len(allowed) > 0 {
+		object.allowed = make(map[string]bool, len(allowed))
+		for _, name := range allowed {
+			object.allowed[name] = true
+		}
+	}
+	return object, nil
+}
+
+// isGoObject reports whether the value can be wrapped in a GoObject
+func isGoObject(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Pointer:
+		return v.Type().Elem().Kind() == reflect.Struct
+	case reflect.Map:
+		return v.Type().Key().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+// get looks up a property on the object. Like for a LoxInstance, fields
+// shadow methods.
+func (o *GoObject) get(name token) (any, error) {
+	if o.allowed != nil && !o.allowed[name.lexeme] {
+		return nil, o.undefined(name)
+	}
+
+	if o.value.Kind() == reflect.Map {
+		entry := o.value.MapIndex(reflect.ValueOf(name.lexeme).Convert(o.value.Type().Key()))
+		if entry.IsValid() {
+			return o.wrap(name, entry)
+		}
+	} else if field, ok := o.field(name.lexeme); ok {
+		return o.wrap(name, field)
+	}
+
+	if method := o.value.MethodByName(name.lexeme); method.IsValid() {
+		native, err := newNativeFunction(name.lexeme, method.Interface())
+		if err != nil {
+			return nil, RuntimeError{
+				token: name,
+				msg:   fmt.Sprintf("Method '%s' can't be called from Lox: %v.", name.lexeme, err),
+			}
+		}
+		return native, nil
+	}
+
+	return nil, o.undefined(name)
+}
+
+func (o *GoObject) set(name token, value any) error {
+	if o.allowed != nil && !o.allowed[name.lexeme] {
+		return o.undefined(name)
+	}
+
+	if o.value.Kind() == reflect.Map {
+		entry, err := fromLox(value, o.value.Type().Elem())
+		if err != nil {
+			return RuntimeError{
+				token: name,
+				msg:   fmt.Sprintf("Entry '%s' %v.", name.lexeme, err),
+			}
+		}
+		o.value.SetMapIndex(reflect.ValueOf(name.lexeme).Convert(o.value.Type().Key()), entry)
+		return nil
+	}
+
+	field, ok := o.field(name.lexeme)
+	if !ok {
+		return o.undefined(name)
+	}
+	if !field.CanSet() {
+		return RuntimeError{
+			token: name,
+			msg:   fmt.Sprintf("Field '%s' is read only.", name.lexeme),
+		}
+	}
+	converted, err := fromLox(value, field.Type())
+	if err != nil {
+		return RuntimeError{
+			token: name,
+			msg:   fmt.Sprintf("Field '%s' %v.", name.lexeme, err),
+		}
+	}
+	field.Set(converted)
+	return nil
+}
+
+// field returns the exported struct field, which may be promoted from an embedded struct
+func (o *GoObject) field(name string) (reflect.Value, bool) {
+	structValue := reflect.Indirect(o.value)
+	structField, ok := structValue.Type().FieldByName(name)
+	if !ok || !structField.IsExported() {
+		return reflect.Value{}, false
+	}
+	field, err := structValue.FieldByIndexErr(structField.Index)
+	if err != nil {
+		// Promoted through a nil embedded pointer
+		return reflect.Value{}, false
+	}
+	return field, true
+}
+
+// wrap converts the field or map entry to a Lox value. Structs and maps in it
+// become GoObjects too, with the same allowed names.
+func (o *GoObject) wrap(name token, v reflect.Value) (any, error) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.Kind() == reflect.Pointer || v.Kind() == reflect.Map) && v.IsNil() {
+		return nil, nil
+	}
+
+	if v.Kind() == reflect.Struct && v.CanAddr() {
+		// Through a pointer, so the fields can be set and all methods called
+		v = v.Addr()
+	}
+	switch value := v.Interface().(type) {
+	case LoxCallable, *LoxInstance, *LoxList, *LoxMap, *LoxError, *LoxModule, *GoObject:
+		// Stored in a map by a script
+		return value, nil
+	}
+	if isGoObject(v) {
+		return &GoObject{value: v, allowed: o.allowed}, nil
+	}
+	value, err := toLox(v.Interface())
+	if err != nil {
+		return nil, RuntimeError{
+			token: name,
+			msg:   fmt.Sprintf("Property '%s' can't be used in Lox: %v.", name.lexeme, err),
+		}
+	}
+	return value, nil
+}
+
+func (o *GoObject) undefined(name token) error {
+	return RuntimeError{
+		token: name,
+		msg:   fmt.Sprintf("Undefined property '%s'.", name.lexeme),
+	}
+}
+
+func (o *GoObject) String() string {
+	return fmt.Sprintf("<go %v>", o.value.Type())
+}
@@ -0,0 +1,160 @@
+package lox
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+type user struct {
+	Name    string
+	Age     int
+	Address *address
+	secret  string
+}
+
+type address struct {
+	City string
+}
+
+func (u *user) Greet(greeting string) string {
+	return greeting + " " + u.Name
+}
+
+func (u user) Initial() string {
+	return u.Name[:1]
+}
+
+// evalWith runs the source with the value set as the global v, and returns
+// what it printed
+func evalWith(t *testing.T, value any, source string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(io.Discard))
+	if err := interpreter.Set("v", value); err != nil {
+		t.Fatal(err)
+	}
+	err := interpreter.Eval(source)
+	return out.String(), err
+}
+
+func TestGoObjectFields(t *testing.T) {
+	u := &user{Name: "Ada", Age: 36, Address: &address{City: "London"}}
+	out, err := evalWith(t, u, `print v.Name; print v.Address.City; v.Age = v.Age + 1; v.Address.City = "Paris";`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Ada\nLondon\n" {
+		t.Errorf("got %q", out)
+	}
+	if u.Age != 37 || u.Address.City != "Paris" {
+		t.Errorf("the script didn't change the struct: %+v %+v", u, u.Address)
+	}
+
+	tests := []struct {
+		value  any
+		source string
+		want   string
+	}{
+		{u, `v.Age = "old";`, "Field 'Age' must be a whole number, got string."},
+		{u, `print v.secret;`, "Undefined property 'secret'."},
+		{u, `v.Missing = 1;`, "Undefined property 'Missing'."},
+		{*u, `v.Name = "Bob";`, "Field 'Name' is read only."},
+	}
+	for _, test := range tests {
+		_, err := evalWith(t, test.value, test.source)
+		if msg := runtimeMessage(t, err); msg != test.want {
+			t.Errorf("%s: got %q, want %q", test.source, msg, test.want)
+		}
+	}
+}
+
+func TestGoObjectMethods(t *testing.T) {
+	out, err := evalWith(t, &user{Name: "Ada"}, `print v.Greet("hi"); print v.Initial(); var greet = v.Greet; print greet("hey");`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "hi Ada\nA\nhey Ada\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	// Methods with a pointer receiver need a pointer
+	_, err = evalWith(t, user{Name: "Ada"}, `v.Greet("hi");`)
+	if msg := runtimeMessage(t, err); msg != "Undefined property 'Greet'." {
+		t.Errorf("got %q", msg)
+	}
+}
+
+func TestGoObjectMap(t *testing.T) {
+	m := map[string]int{"a": 1}
+	out, err := evalWith(t, m, `print v.a; v.b = 2;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "1\n" || m["b"] != 2 {
+		t.Errorf("got %q and %v", out, m)
+	}
+
+	_, err = evalWith(t, m, `v.c = "three";`)
+	if msg := runtimeMessage(t, err); msg != "Entry 'c' must be a whole number, got string." {
+		t.Errorf("got %q", msg)
+	}
+}
+
+func TestGoObjectAllowed(t *testing.T) {
+	u := &user{Name: "Ada", Age: 36, Address: &address{City: "London"}}
+	object, err := NewGoObject(u, "Name", "Address", "City")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := evalWith(t, object, `print v.Name; print v.Address.City;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Ada\nLondon\n" {
+		t.Errorf("got %q", out)
+	}
+
+	for _, source := range []string{`print v.Age;`, `v.Age = 1;`, `v.Greet("hi");`} {
+		_, err := evalWith(t, object, source)
+		if msg := runtimeMessage(t, err); !strings.HasPrefix(msg, "Undefined property") {
+			t.Errorf("%s: got %q", source, msg)
+		}
+	}
+	if u.Age != 36 {
+		t.Errorf("a field that isn't allowed was set")
+	}
+
+	if _, err := NewGoObject(42); err == nil {
+		t.Error("NewGoObject accepted an int")
+	}
+}
+
+func TestNilGoValues(t *testing.T) {
+	var out bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(io.Discard))
+	var m map[string]any
+	if err := interpreter.Set("m", m); err != nil {
+		t.Fatal(err)
+	}
+	find := func(name string) *user {
+		if name == "ada" {
+			return &user{Name: "Ada"}
+		}
+		return nil
+	}
+	if err := interpreter.RegisterFunction("find", find); err != nil {
+		t.Fatal(err)
+	}
+	if err := interpreter.Eval(`print m == nil; print find("bob") == nil; print find("ada").Name;`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "true\ntrue\nAda\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := NewGoObject(m); err == nil {
+		t.Error("NewGoObject accepted a nil map")
+	}
+}
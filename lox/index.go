@@ -0,0 +1,28 @@
+package lox
+
+import "errors"
+
+// getIndex returns object[index] for lists and maps. Both backends raise the
+// error as a RuntimeError at the closing bracket.
+func getIndex(object any, index any) (any, error) {
+	switch t := object.(type) {
+	case *LoxList:
+		return t.get(index)
+	case *LoxMap:
+		return t.get(index)
+	default:
+		return nil, errors.New("Only lists and maps can be indexed.")
+	}
+}
+
+// setIndex does object[index] = value for lists and maps, like getIndex
+func setIndex(object any, index any, value any) error {
+	switch t := object.(type) {
+	case *LoxList:
+		return t.set(index, value)
+	case *LoxMap:
+		return t.set(index, value)
+	default:
+		return errors.New("Only lists and maps can be indexed.")
+	}
+}
@@ -0,0 +1,1098 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+type Interpreter struct {
+	// globals holds the globals of the code being run, the script or one of
+	// the modules it imports. builtins holds the native functions, which
+	// the globals of the script start with and modules see through, but
+	// can't assign, see assignGlobal.
+	globals  *environment
+	builtins *environment
+	// scriptGlobals holds the globals of the script, which Eval runs in and
+	// Get and Set use, even when a native function calls them from a module
+	scriptGlobals *environment
+	// environment holds the variables of the innermost scope being run
+	environment *environment
+	// evals is the number of calls to Eval so far, which names their source.
+	// running is the number of calls from the host in progress, see enter.
+	evals   int
+	running int
+	// locals holds the scope depth of every resolved local variable in the
+	// code being run. Every Eval and module gets its own, and functions keep
+	// the one of the code they were declared in, like globals, so the entries
+	// go away together with the code instead of piling up.
+	// https://craftinginterpreters.com/resolving-and-binding.html#resolving-variable-declarations
+	locals map[expression]int
+	// frames holds a frame for every Lox function being called, for stack traces.
+	// A frame is only popped when the call returns normally, so when a
+	// RuntimeError reaches interpret() the stack still shows where it happened.
+	frames stack[StackFrame]
+	// depth is the number of Lox function calls in progress, and maxDepth
+	// how many there can be before we report a stack overflow
+	depth    int
+	maxDepth int
+
+	// The execution budget, see budget.go. steps is the number of steps
+	// taken in the current call to interpret.
+	ctx      context.Context
+	maxSteps int
+	deadline time.Time
+	steps    int
+
+	// stdout is where print statements write to, and stderr where errors are
+	// reported by Eval, EvalFile and CallFunction, rendered by renderer
+	stdout   io.Writer
+	stderr   io.Writer
+	renderer *renderer
+
+	// vm runs the programs compiled with Compile, see Run. It is created
+	// by the first call to Run.
+	vm *virtualMachine
+
+	// modules runs the modules imported by scripts, searching modulePath.
+	// noImports and moduleRoot restrict what they can import.
+	modules    *moduleLoader
+	modulePath []string
+	noImports  bool
+	moduleRoot string
+}
+
+// DEFAULT_MAX_DEPTH is how deep calls can nest, in both backends, unless configured otherwise
+const DEFAULT_MAX_DEPTH = 1000
+
+// MAX_DEPTH_LIMIT is the highest max depth that can be configured. Every Lox
+// call takes many Go frames in the tree-walking interpreter, tens of
+// kilobytes for a call nested in loops and expressions, so much deeper calls
+// would overflow the Go stack and crash the whole process.
+const MAX_DEPTH_LIMIT = 10000
+
+// InterpreterOption configures an Interpreter, see New
+type InterpreterOption func(*Interpreter)
+
+// WithMaxDepth sets how deep Lox function calls can nest before they fail
+// with a "Stack overflow." RuntimeError. Without a limit, unbounded recursion
+// would grow the Go stack until the whole process crashes. Depths below 1 are
+// raised to 1, and depths above MAX_DEPTH_LIMIT lowered to it.
+func WithMaxDepth(depth int) InterpreterOption {
+	if depth < 1 {
+		depth = 1
+	} else if depth > MAX_DEPTH_LIMIT {
+		depth = MAX_DEPTH_LIMIT
+	}
+	return func(i *Interpreter) {
+		i.maxDepth = depth
+	}
+}
+
+// WithStdout sets where print statements write to, os.Stdout by default
+func WithStdout(w io.Writer) InterpreterOption {
+	return func(i *Interpreter) {
+		i.stdout = w
+	}
+}
+
+// WithStderr sets where errors are reported, os.Stderr by default
+func WithStderr(w io.Writer) InterpreterOption {
+	return func(i *Interpreter) {
+		i.stderr = w
+	}
+}
+
+// WithColor sets whether errors are reported with ANSI colors, which they
+// aren't by default
+func WithColor(enabled bool) InterpreterOption {
+	return func(i *Interpreter) {
+		i.renderer.color = enabled
+	}
+}
+
+// WithModulePath sets the directories searched for imported modules, after
+// the directory of the importing script. The glox command uses GLOX_PATH.
+func WithModulePath(dirs ...string) InterpreterOption {
+	return func(i *Interpreter) {
+		i.modulePath = dirs
+	}
+}
+
+// WithStringFunctions defines the native functions for strings, like split
+// and substring. Hosts that only want the core language leave them out.
+func WithStringFunctions() InterpreterOption {
+	return func(i *Interpreter) {
+		i.defineNatives(stringNatives)
+	}
+}
+
+// WithImports sets whether scripts can import modules, which they can by
+// default. Hosts that don't want scripts to read files turn it off.
+func WithImports(enabled bool) InterpreterOption {
+	return func(i *Interpreter) {
+		i.noImports = !enabled
+	}
+}
+
+// WithModuleRoot only lets scripts import the files in the directory, and
+// the directories below it. Absolute paths are refused, and so are relative
+// paths, symbolic links and module path directories leading out of it.
+// Code run with Eval imports relative to the directory.
+func WithModuleRoot(dir string) InterpreterOption {
+	return func(i *Interpreter) {
+		i.moduleRoot = dir
+	}
+}
+
+// New returns an interpreter with the native functions defined.
+// Interpreters don't share any state, so many can run side by side.
+func New(options ...InterpreterOption) *Interpreter {
+	interpreter := &Interpreter{
+		builtins: newEnvironment(nil),
+		locals:   make(map[expression]int),
+		maxDepth: DEFAULT_MAX_DEPTH,
+		ctx:      context.Background(),
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+		renderer: newRenderer(),
+	}
+	// The script can assign to them without changing them for modules
+	interpreter.globals = newEnvironment(nil)
+	interpreter.environment = interpreter.globals
+	interpreter.scriptGlobals = interpreter.globals
+	interpreter.builtins.define("clock", &clock{})
+	interpreter.globals.define("clock", &clock{})
+	interpreter.defineNatives(append(listNatives, mapNatives...))
+
+	for _, option := range options {
+		option(interpreter)
+	}
+
+	interpreter.modules = newModuleLoader(interpreter.renderer, interpreter.stderr, interpreter.modulePath)
+	interpreter.modules.disabled = interpreter.noImports
+	interpreter.modules.root = interpreter.moduleRoot
+	return interpreter
+}
+
+// defineNatives defines the native functions for the script and the modules it imports
+func (i *Interpreter) defineNatives(natives []*nativeFunction) {
+	for _, native := range natives {
+		i.builtins.define(native.name, native)
+		i.scriptGlobals.define(native.name, native)
+	}
+	if i.vm != nil {
+		i.vm.defineNatives(natives)
+	}
+}
+
+// interpret runs the program, and stops at the first RuntimeError, or
+// BudgetError if the script ran out of its execution budget, which is returned
+func (i *Interpreter) interpret(statements []statement) error {
+	for _, statement := range statements {
+		_, err := i.execute(statement)
+		if err != nil {
+			return i.unwind(err)
+		}
+	}
+	return nil
+}
+
+// enter starts running code for the host, in Eval or CallFunction, and
+// returns the function that ends it. Native functions can call back into the
+// interpreter while a script is running, so the state of the code being run
+// is saved and restored, and only the outermost call gets a fresh execution
+// budget. Nested calls count towards the budget and the max depth.
+func (i *Interpreter) enter() (exit func()) {
+	globals, environment, locals := i.globals, i.environment, i.locals
+	frames, depth := i.frames.Len(), i.depth
+	if i.running == 0 {
+		i.steps = 0
+	}
+	i.running++
+	return func() {
+		i.running--
+		i.globals, i.environment, i.locals = globals, environment, locals
+		// Frames are left on the stack when a call fails, for the stack trace
+		i.frames.Truncate(frames)
+		i.depth = depth
+	}
+}
+
+// unwind returns the RuntimeError or BudgetError in err, after it stopped
+// the program. RuntimeErrors get the stack trace.
+func (i *Interpreter) unwind(err error) error {
+	var runtimeErr RuntimeError
+	var budgetErr BudgetError
+	var staticErr StaticError
+	if errors.As(err, &runtimeErr) {
+		// Errors caught and thrown again already have the trace from where they happened
+		if runtimeErr.trace == nil {
+			runtimeErr.trace = i.stackTrace()
+		}
+		err = runtimeErr
+	} else if errors.As(err, &budgetErr) {
+		err = budgetErr
+	} else if errors.As(err, &staticErr) {
+		// An imported module can't run, its errors are already reported
+		err = staticErr
+	} else {
+		panic(err)
+	}
+	return err
+}
+
+// stackTrace returns the frames of the calls in progress, innermost first
+func (i *Interpreter) stackTrace() []StackFrame {
+	frames := i.frames.Items()
+	trace := make([]StackFrame, 0, len(frames))
+	for j := len(frames) - 1; j >= 0; j-- {
+		trace = append(trace, frames[j])
+	}
+	return trace
+}
+
+// frameName returns the name to show in stack traces for a call to the
+// callable, and false for calls that don't get a frame. Like in the VM,
+// natives and classes without an initializer don't.
+func frameName(callable LoxCallable) (string, bool) {
+	switch t := callable.(type) {
+	case *LoxFunction:
+		return t.declaration.name.lexeme, true
+	case *LoxClass:
+		if initializer := t.findMethod("init"); initializer != nil {
+			return initializer.declaration.name.lexeme, true
+		}
+	}
+	return "", false
+}
+
+func (i *Interpreter) resolve(expr expression, depth int) {
+	i.locals[expr] = depth
+}
+
+func checkNumberOperand(operator token, operand any) error {
+	_, ok := operand.(float64)
+	if !ok {
+		return RuntimeError{
+			token: operator,
+			msg:   "Operand must be a number",
+		}
+	}
+	return nil
+}
+
+func checkNumberOperands(operator token, left any, right any) error {
+	_, lok := left.(float64)
+	_, rok := right.(float64)
+
+	if !lok || !rok {
+		return RuntimeError{
+			token: operator,
+			msg:   "Operand must be a number",
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) evaluate(expr expression) (any, error) {
+	switch t := expr.(type) {
+	case *binaryExpr:
+		return i.visitBinaryExpr(t)
+	case *groupingExpr:
+		return i.visitGroupingExpr(t)
+	case *indexExpr:
+		return i.visitIndexExpr(t)
+	case *interpolationExpr:
+		return i.visitInterpolationExpr(t)
+	case *listExpr:
+		return i.visitListExpr(t)
+	case *literalExpr:
+		return i.visitLiteralExpr(t), nil
+	case *unaryExpr:
+		return i.visitUnaryExpr(t)
+	case *variableExpr:
+		return i.visitVariableExpr(t)
+	case *logicalExpr:
+		return i.visitLogicalExpr(t)
+	case *assignExpr:
+		return i.visitAssignExpr(t)
+	case *callExpr:
+		return i.visitCallExpr(t)
+	case *getExpr:
+		return i.visitGetExpr(t)
+	case *mapExpr:
+		return i.visitMapExpr(t)
+	case *setExpr:
+		return i.visitSetExpr(t)
+	case *setIndexExpr:
+		return i.visitSetIndexExpr(t)
+	case *superExpr:
+		return i.visitSuperExpr(t)
+	case *thisExpr:
+		return i.visitThisExpr(t)
+	default:
+		panic(fmt.Sprintf("eval: unknown type %T: %v", expr, t))
+	}
+}
+
+// execute runs a single statement and reports how it completed,
+// so that return statements can unwind to the enclosing function
+func (i *Interpreter) execute(stmt statement) (completion, error) {
+	if err := i.step(stmt.span()); err != nil {
+		return normalCompletion, err
+	}
+
+	switch t := stmt.(type) {
+	case printStmt:
+		return normalCompletion, i.visitPrintStmt(t)
+	case varStmt:
+		return normalCompletion, i.visitVarStmt(t)
+	case importStmt:
+		return normalCompletion, i.visitImportStmt(t)
+	case expressionStmt:
+		return normalCompletion, i.visitExpressionStmt(t)
+	case blockStmt:
+		return i.visitBlockStmt(t)
+	case ifStmt:
+		completion, err := i.visitIfStmt(t)
+		if err != nil {
+			return completion, fmt.Errorf("visiting if statemenet: %w", err)
+		}
+		return completion, nil
+	case whileStmt:
+		completion, err := i.visitWhileStmt(t)
+		if err != nil {
+			return completion, fmt.Errorf("visiting wihle statement: %w", err)
+		}
+		return completion, nil
+	case functionStmt:
+		i.visitFunctionStmt(t)
+		return normalCompletion, nil
+	case classStmt:
+		if err := i.visitClassStmt(t); err != nil {
+			return normalCompletion, fmt.Errorf("visiting class statement: %w", err)
+		}
+		return normalCompletion, nil
+	case returnStmt:
+		return i.visitReturnStmt(t)
+	case throwStmt:
+		return i.visitThrowStmt(t)
+	case tryStmt:
+		return i.visitTryStmt(t)
+	case breakStmt:
+		return completion{completionType: completionBreak}, nil
+	case continueStmt:
+		return completion{completionType: completionContinue}, nil
+	default:
+		panic(fmt.Sprintf("executing: unknown type %T: %v", stmt, t))
+	}
+}
+
+// executeBlock runs the statements in the given environment, and stops
+// at the first statement that does not complete normally
+func (i *Interpreter) executeBlock(statements []statement, environment *environment) (completion, error) {
+	// https://craftinginterpreters.com/statements-and-state.html#block-syntax-and-semantics
+	previous := i.environment
+	defer func() {
+		i.environment = previous
+	}()
+
+	i.environment = environment
+	for _, statement := range statements {
+		completion, err := i.execute(statement)
+		if err != nil {
+			return completion, err
+		}
+		if completion.abrupt() {
+			return completion, nil
+		}
+	}
+	return normalCompletion, nil
+}
+
+func (i *Interpreter) visitBlockStmt(stmt blockStmt) (completion, error) {
+	return i.executeBlock(stmt.statements, newEnvironment(i.environment))
+}
+
+// https://craftinginterpreters.com/classes.html#class-declarations
+func (i *Interpreter) visitClassStmt(stmt classStmt) error {
+	var superclass *LoxClass = nil
+	if stmt.superclass != nil {
+		value, err := i.evaluate(stmt.superclass)
+		if err != nil {
+			return fmt.Errorf("evaluating superclass: %w", err)
+		}
+		tmp, ok := value.(*LoxClass)
+		if !ok {
+			return RuntimeError{
+				token: stmt.superclass.name,
+				msg:   "Superclass must be a class.",
+			}
+		}
+		superclass = tmp
+	}
+
+	i.environment.define(stmt.name.lexeme, nil)
+
+	if superclass != nil {
+		i.environment = newEnvironment(i.environment)
+		i.environment.define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction)
+	for _, method := range stmt.methods {
+		function := newLoxFunction(method, i.environment, i.globals, i.locals, method.name.lexeme == "init")
+		methods[method.name.lexeme] = function
+	}
+
+	class := newLoxClass(stmt.name.lexeme, superclass, methods)
+
+	if superclass != nil {
+		i.environment = i.environment.enclosing
+	}
+
+	return i.environment.assign(stmt.name, class)
+}
+
+func (i *Interpreter) visitExpressionStmt(stmt expressionStmt) error {
+	_, err := i.evaluate(stmt.expression)
+	return err
+}
+
+func (i *Interpreter) visitFunctionStmt(stmt functionStmt) {
+	function := newLoxFunction(stmt, i.environment, i.globals, i.locals, false)
+	i.environment.define(stmt.name.lexeme, function)
+}
+
+// https://craftinginterpreters.com/control-flow.html#conditional-execution
+func (i *Interpreter) visitIfStmt(stmt ifStmt) (completion, error) {
+	evres, err := i.evaluate(stmt.condition)
+	if err != nil {
+		return normalCompletion, fmt.Errorf("evaluating condition: %w", err)
+	}
+	if isTruthy(evres) {
+		return i.execute(stmt.thenBranch)
+	} else if stmt.elseBranch != nil {
+		return i.execute(stmt.elseBranch)
+	}
+	return normalCompletion, nil
+}
+
+func (i *Interpreter) visitPrintStmt(stmt printStmt) error {
+	var value any
+	var err error
+	value, err = i.evaluate(stmt.expression)
+	if err != nil {
+		return fmt.Errorf("evaluating print printstmt expression: %w", err)
+	}
+	fmt.Fprintln(i.stdout, stringify(value))
+	return nil
+}
+
+func (i *Interpreter) visitReturnStmt(stmt returnStmt) (completion, error) {
+	var value any = nil
+	if stmt.value != nil {
+		tmp, err := i.evaluate(stmt.value)
+		if err != nil {
+			return normalCompletion, err
+		}
+		value = tmp
+	}
+
+	// The book throws a Java exception here, instead we hand the value
+	// back up through the enclosing statements until LoxFunction.Call sees it
+	return completion{
+		completionType: completionReturn,
+		value:          value,
+	}, nil
+}
+
+func (i *Interpreter) visitVarStmt(stmt varStmt) error {
+	var value any = nil
+	if stmt.initializer != nil {
+		v, err := i.evaluate(stmt.initializer)
+		if err != nil {
+			return fmt.Errorf("evaluating initializer: %w", err)
+		}
+		value = v
+	}
+	i.environment.define(stmt.name.lexeme, value)
+	return nil
+}
+
+func (i *Interpreter) visitImportStmt(stmt importStmt) error {
+	module, err := i.modules.load(stmt.keyword, stmt.path.literal.(string), i.runModule)
+	if err != nil {
+		return err
+	}
+	i.environment.define(stmt.name.lexeme, module)
+	return nil
+}
+
+// runModule runs the source of an imported module with its own globals, and
+// returns them. Modules only see the native functions of the script.
+func (i *Interpreter) runModule(file string, source string) (map[string]any, error) {
+	globals, environment, locals := i.globals, i.environment, i.locals
+	defer func() {
+		i.globals, i.environment, i.locals = globals, environment, locals
+	}()
+	i.locals = make(map[expression]int)
+	statements, err := i.check(file, source)
+	if err != nil {
+		return nil, err
+	}
+
+	i.globals = newEnvironment(i.builtins)
+	i.environment = i.globals
+
+	for _, statement := range statements {
+		if _, err := i.execute(statement); err != nil {
+			return nil, err
+		}
+	}
+	return i.globals.values, nil
+}
+
+func (i *Interpreter) visitThrowStmt(stmt throwStmt) (completion, error) {
+	value, err := i.evaluate(stmt.value)
+	if err != nil {
+		return normalCompletion, fmt.Errorf("evaluating thrown value: %w", err)
+	}
+	return normalCompletion, throwError(stmt.keyword, value)
+}
+
+// visitTryStmt runs the body, then the catch clause if the body raised a
+// runtime error, and then the finally clause however the others completed.
+// A finally clause that completes abruptly itself wins over the others.
+func (i *Interpreter) visitTryStmt(stmt tryStmt) (completion, error) {
+	frames, depth := i.frames.Len(), i.depth
+
+	completion, err := i.visitBlockStmt(stmt.body)
+	runtimeErr, caught := i.catch(err, frames, depth)
+	if caught {
+		err = runtimeErr
+	}
+	if caught && stmt.catch != nil {
+		environment := newEnvironment(i.environment)
+		environment.define(stmt.catch.name.lexeme, caughtValue(runtimeErr))
+		completion, err = i.executeBlock(stmt.catch.body, environment)
+		if runtimeErr, caught := i.catch(err, frames, depth); caught {
+			err = runtimeErr
+		}
+	}
+
+	if stmt.finally == nil {
+		return completion, err
+	}
+	if _, ok := isCatchable(err); err != nil && !ok {
+		// The budget ran out, nothing more may run
+		return completion, err
+	}
+	finallyCompletion, finallyErr := i.visitBlockStmt(*stmt.finally)
+	if finallyErr != nil || finallyCompletion.abrupt() {
+		return finallyCompletion, finallyErr
+	}
+	return completion, err
+}
+
+// catch returns the RuntimeError in err, if a try statement can catch it.
+// The calls made since the try began, frames and depth, won't return, so
+// they are dropped, after being saved in the stack trace of the error.
+func (i *Interpreter) catch(err error, frames int, depth int) (RuntimeError, bool) {
+	runtimeErr, ok := isCatchable(err)
+	if !ok {
+		return runtimeErr, false
+	}
+	if runtimeErr.trace == nil {
+		runtimeErr.trace = i.stackTrace()
+	}
+	i.frames.Truncate(frames)
+	i.depth = depth
+	return runtimeErr, true
+}
+
+func (i *Interpreter) visitWhileStmt(stmt whileStmt) (completion, error) {
+	for {
+		if err := i.step(stmt.span()); err != nil {
+			return normalCompletion, err
+		}
+
+		condEvald, err := i.evaluate(stmt.condition)
+		if err != nil {
+			return normalCompletion, fmt.Errorf("evaluating stmt condition in while loop: %w", err)
+		}
+		if !isTruthy(condEvald) {
+			break
+		}
+
+		completion, err := i.execute(stmt.body)
+		if err != nil {
+			return completion, fmt.Errorf("executing while body: %w", err)
+		}
+
+		switch completion.completionType {
+		case completionBreak:
+			return normalCompletion, nil
+		case completionReturn:
+			return completion, nil
+		}
+
+		// Normal and continue completions go on to the next iteration
+		if stmt.increment != nil {
+			if _, err := i.evaluate(stmt.increment); err != nil {
+				return normalCompletion, fmt.Errorf("evaluating increment in for loop: %w", err)
+			}
+		}
+	}
+	return normalCompletion, nil
+}
+
+func (i *Interpreter) visitAssignExpr(expr *assignExpr) (any, error) {
+	value, err := i.evaluate(expr.value)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating assignment expression: %w", err)
+	}
+
+	if distance, ok := i.locals[expr]; ok {
+		i.environment.assignAt(distance, expr.name, value)
+	} else if err := i.assignGlobal(expr.name, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func isTruthy(object any) bool {
+	if object == nil {
+		return false
+	}
+	if res, ok := object.(bool); ok {
+		return res
+	}
+	return true
+}
+
+func isEqual(a any, b any) bool {
+	if a == nil && b == nil {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+
+	// TODO I think this should work https://go.dev/play/p/0T79bqDjO8_B
+	// But not 100% sure it works properly for all cases
+	return a == b
+}
+
+func stringify(object any) string {
+	return fmt.Sprintf("%v", object)
+}
+
+func (i *Interpreter) visitBinaryExpr(expr *binaryExpr) (any, error) {
+	left, err := i.evaluate(expr.left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := i.evaluate(expr.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.operator.tokenType {
+	case tokenGreater:
+		if err := checkNumberOperands(expr.operator, left, right); err != nil {
+			return nil, fmt.Errorf("checking binary greater than: %w", err)
+		}
+		return left.(float64) > right.(float64), nil
+	case tokenGreaterEqual:
+		if err := checkNumberOperands(expr.operator, left, right); err != nil {
+			return nil, fmt.Errorf("checking binary greater than or equal: %w", err)
+		}
+		return left.(float64) >= right.(float64), nil
+	case tokenLess:
+		if err := checkNumberOperands(expr.operator, left, right); err != nil {
+			return nil, fmt.Errorf("checking binary less than: %w", err)
+		}
+		return left.(float64) < right.(float64), nil
+	case tokenLessEqual:
+		if err := checkNumberOperands(expr.operator, left, right); err != nil {
+			return nil, fmt.Errorf("checking binary less than or equal: %w", err)
+		}
+		return left.(float64) <= right.(float64), nil
+	case tokenMinus:
+		if err := checkNumberOperands(expr.operator, left, right); err != nil {
+			return nil, fmt.Errorf("checking binary subtraction: %w", err)
+		}
+		return left.(float64) - right.(float64), nil
+	case tokenBangEqual:
+		return !isEqual(left, right), nil
+	case tokenEqualEqual:
+		return isEqual(left, right), nil
+	case tokenPlus:
+		// Pluss is a bit special because it works for
+		// numbers and strings
+		{
+			l, lok := left.(float64)
+			r, rok := right.(float64)
+			if lok && rok {
+				return l + r, nil
+			}
+		}
+		{
+			l, lok := left.(string)
+			r, rok := right.(string)
+			if lok && rok {
+				return l + r, nil
+			}
+		}
+		return nil, fmt.Errorf("checking plus (could be number or string): %w", RuntimeError{
+			token: expr.operator,
+			msg: fmt.Sprintf("Operands must be two numbers or two strings, got %s and %s.",
+				typeName(left), typeName(right)),
+		})
+	case tokenSlash:
+		if err := checkNumberOperands(expr.operator, left, right); err != nil {
+			return nil, fmt.Errorf("checking binary division (SLASH): %w", err)
+		}
+		// Check if we are dividing by zero
+		rval := right.(float64)
+		if rval == 0.0 {
+			return nil, RuntimeError{
+				token: expr.operator,
+				msg:   "divide by zero",
+			}
+		}
+		return left.(float64) / right.(float64), nil
+	case tokenStar:
+		if err := checkNumberOperands(expr.operator, left, right); err != nil {
+			return nil, fmt.Errorf("checking binary multiplication (STAR): %w", err)
+		}
+		return left.(float64) * right.(float64), nil
+	}
+
+	// Unreachable
+	panic("eval binary: should never get here...")
+}
+
+func (i *Interpreter) visitCallExpr(expr *callExpr) (any, error) {
+	callee, err := i.evaluate(expr.callee)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate(): %w", err)
+	}
+
+	var arguments []any
+	for _, argument := range expr.arguments {
+		e, err := i.evaluate(argument)
+		if err != nil {
+			return nil, fmt.Errorf("evaluate(): %w", err)
+		}
+		arguments = append(
+			arguments,
+			e,
+		)
+	}
+
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		return nil, RuntimeError{
+			token: expr.paren,
+			msg:   "Can only call functions and classes.",
+		}
+	}
+	if msg := arityError(function, len(arguments)); msg != "" {
+		return nil, RuntimeError{
+			token: expr.paren,
+			msg:   msg,
+		}
+	}
+
+	if err := i.step(expr.span()); err != nil {
+		return nil, err
+	}
+
+	name, hasFrame := frameName(function)
+	if hasFrame {
+		i.frames.Push(StackFrame{function: name, callSite: expr.paren})
+	}
+
+	res, err := function.Call(i, arguments)
+	if err != nil {
+		// The frame is left on the stack for the stack trace. RuntimeErrors
+		// are passed on as they are, wrapping them on every call would make
+		// unwinding from deep recursion quadratic in the depth.
+		var runtimeErr RuntimeError
+		if errors.As(err, &runtimeErr) {
+			return nil, runtimeErr
+		}
+		var budgetErr BudgetError
+		if errors.As(err, &budgetErr) {
+			return nil, budgetErr
+		}
+		// Any other error comes from a native function, and is raised at the call
+		return nil, RuntimeError{
+			token: expr.paren,
+			msg:   err.Error(),
+			err:   err,
+		}
+	}
+
+	if hasFrame {
+		i.frames.Pop()
+	}
+	return res, nil
+}
+
+// https://craftinginterpreters.com/classes.html#properties-on-instances
+func (i *Interpreter) visitGetExpr(expr *getExpr) (any, error) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating get object: %w", err)
+	}
+
+	switch t := object.(type) {
+	case *LoxInstance:
+		return t.get(expr.name)
+	case *GoObject:
+		return t.get(expr.name)
+	case *LoxError:
+		return t.get(expr.name)
+	case *LoxModule:
+		return t.get(expr.name)
+	default:
+		return nil, RuntimeError{
+			token: expr.name,
+			msg:   "Only instances have properties.",
+		}
+	}
+}
+
+func (i *Interpreter) visitGroupingExpr(expr *groupingExpr) (any, error) {
+	return i.evaluate(expr.expression)
+}
+
+func (i *Interpreter) visitLiteralExpr(expr *literalExpr) any {
+	return expr.value
+}
+
+func (i *Interpreter) visitLogicalExpr(expr *logicalExpr) (any, error) {
+	left, err := i.evaluate(expr.left)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating left expr of logical expr: %w", err)
+	}
+
+	if expr.operator.tokenType == tokenOr {
+		if isTruthy(left) {
+			return left, nil
+		}
+	} else {
+		// I think this branch means that we assume expr.operator.tokenType == tokenAnd ?
+		// See chapter 9.3
+		if !isTruthy(left) {
+			return left, nil
+		}
+	}
+
+	res, err := i.evaluate(expr.right)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating right expr: %w", err)
+	}
+	return res, nil
+}
+
+func (i *Interpreter) visitSetExpr(expr *setExpr) (any, error) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating set object: %w", err)
+	}
+
+	switch object.(type) {
+	case *LoxInstance, *GoObject:
+	default:
+		return nil, RuntimeError{
+			token: expr.name,
+			msg:   "Only instances have fields.",
+		}
+	}
+
+	value, err := i.evaluate(expr.value)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating set value: %w", err)
+	}
+	if goObject, ok := object.(*GoObject); ok {
+		if err := goObject.set(expr.name, value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	object.(*LoxInstance).set(expr.name, value)
+	return value, nil
+}
+
+// https://craftinginterpreters.com/inheritance.html#semantics
+func (i *Interpreter) visitSuperExpr(expr *superExpr) (any, error) {
+	distance := i.locals[expr]
+	superclass := i.environment.getAt(distance, "super").(*LoxClass)
+
+	// "this" is always bound in the environment right inside the one holding "super"
+	object := i.environment.getAt(distance-1, "this").(*LoxInstance)
+
+	method := superclass.findMethod(expr.method.lexeme)
+	if method == nil {
+		return nil, RuntimeError{
+			token: expr.method,
+			msg:   fmt.Sprintf("Undefined property '%s'.", expr.method.lexeme),
+		}
+	}
+	return method.bind(object), nil
+}
+
+func (i *Interpreter) visitThisExpr(expr *thisExpr) (any, error) {
+	return i.lookUpVariable(expr.keyword, expr)
+}
+
+func (i *Interpreter) visitUnaryExpr(expr *unaryExpr) (any, error) {
+	right, err := i.evaluate(expr.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.operator.tokenType {
+	case tokenBang:
+		return !isTruthy(right), nil
+	case tokenMinus:
+		if err := checkNumberOperand(expr.operator, right); err != nil {
+			return nil, fmt.Errorf("checking minus operand: %w", err)
+		}
+		return -(right.(float64)), nil
+	}
+
+	// Unreachable
+	panic("eval unary: should never get here...")
+}
+
+// assignGlobal assigns the global variable of the script or module being
+// run. Unlike reading one, it doesn't go on to the builtins modules see
+// through, so a module can't replace a native function for the script and
+// the other modules. The VM doesn't either.
+func (i *Interpreter) assignGlobal(name token, value any) error {
+	if _, ok := i.globals.values[name.lexeme]; !ok {
+		return RuntimeError{
+			token: name,
+			msg:   fmt.Sprintf("Tried to assign undefined variable: Undefined variable '%s'.", name.lexeme),
+		}
+	}
+	i.globals.define(name.lexeme, value)
+	return nil
+}
+
+func (i *Interpreter) visitVariableExpr(expr *variableExpr) (any, error) {
+	return i.lookUpVariable(expr.name, expr)
+}
+
+func (i *Interpreter) lookUpVariable(name token, expr expression) (any, error) {
+	if distance, ok := i.locals[expr]; ok {
+		return i.environment.getAt(distance, name.lexeme), nil
+	}
+	return i.globals.get(name)
+}
+
+// visitInterpolationExpr joins the parts, each printed like print does
+func (i *Interpreter) visitInterpolationExpr(expr *interpolationExpr) (any, error) {
+	var builder strings.Builder
+	for _, part := range expr.parts {
+		value, err := i.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(stringify(value))
+	}
+	return builder.String(), nil
+}
+
+func (i *Interpreter) visitListExpr(expr *listExpr) (any, error) {
+	elements := make([]any, 0, len(expr.elements))
+	for _, element := range expr.elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+	return NewLoxList(elements), nil
+}
+
+// visitMapExpr evaluates all keys and values in order before the map is made,
+// like in the VM. Later entries win if a key is repeated.
+func (i *Interpreter) visitMapExpr(expr *mapExpr) (any, error) {
+	entries := make([]any, 0, 2*len(expr.keys))
+	for n := range expr.keys {
+		key, err := i.evaluate(expr.keys[n])
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.evaluate(expr.values[n])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, key, value)
+	}
+
+	m := NewLoxMap()
+	for n := 0; n < len(entries); n += 2 {
+		if err := m.set(entries[n], entries[n+1]); err != nil {
+			return nil, RuntimeError{
+				token: expr.brace,
+				msg:   err.Error(),
+			}
+		}
+	}
+	return m, nil
+}
+
+func (i *Interpreter) visitIndexExpr(expr *indexExpr) (any, error) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expr.index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := getIndex(object, index)
+	if err != nil {
+		return nil, RuntimeError{
+			token: expr.bracket,
+			msg:   err.Error(),
+		}
+	}
+	return value, nil
+}
+
+func (i *Interpreter) visitSetIndexExpr(expr *setIndexExpr) (any, error) {
+	object, err := i.evaluate(expr.object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expr.index)
+	if err != nil {
+		return nil, err
+	}
+	value, err := i.evaluate(expr.value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setIndex(object, index, value); err != nil {
+		return nil, RuntimeError{
+			token: expr.bracket,
+			msg:   err.Error(),
+		}
+	}
+	return value, nil
+}
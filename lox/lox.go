@@ -0,0 +1,262 @@
+// Package lox runs Lox, the language from the book Crafting Interpreters
+// https://craftinginterpreters.com
+//
+// It can be embedded in Go programs:
+//
+//	interpreter := lox.New(lox.WithStdout(&out))
+//	if err := interpreter.Eval(`fun add(a, b) { return a + b; }`); err != nil {
+//		return err
+//	}
+//	sum, err := interpreter.CallFunction("add", 1, 2)
+//
+// Lox numbers are float64, strings are string, booleans are bool and nil is nil.
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// StaticError is returned when the code can't run, because of syntax or
+// resolution errors. Running stops before any of the code is executed, or
+// at the import of a module with errors.
+type StaticError struct {
+	// diagnostics holds the errors, in the order they appear in the source
+	diagnostics []Diagnostic
+}
+
+// Error formats the first error as file:line:col: message, see Diagnostics
+// for all of them
+func (e StaticError) Error() string {
+	first := e.diagnostics[0]
+	msg := fmt.Sprintf("%v: %v", first.span, first.message)
+	if len(e.diagnostics) > 1 {
+		msg += fmt.Sprintf(" (and %d more errors)", len(e.diagnostics)-1)
+	}
+	return msg
+}
+
+// Diagnostics returns the errors, in the order they appear in the source
+func (e StaticError) Diagnostics() []Diagnostic {
+	return e.diagnostics
+}
+
+// newStaticError returns a StaticError holding the errors among the diagnostics
+func newStaticError(diagnostics []Diagnostic) StaticError {
+	var errs []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if diagnostic.severity == SEVERITY_ERROR {
+			errs = append(errs, diagnostic)
+		}
+	}
+	return StaticError{diagnostics: errs}
+}
+
+// Eval runs the source code. Globals defined by earlier calls are still there.
+// Errors are reported to stderr, and returned: a StaticError if the code
+// can't run, and a RuntimeError or BudgetError if it stopped while running.
+// Each call is named <eval#n> in error messages, n counting from 1.
+func (i *Interpreter) Eval(source string) error {
+	// A name of its own, so errors in functions from earlier calls show their source
+	i.evals++
+	return i.EvalSource(fmt.Sprintf("<eval#%d>", i.evals), source)
+}
+
+// EvalFile runs the script at path, like Eval
+func (i *Interpreter) EvalFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading script: %w", err)
+	}
+	return i.EvalSource(path, string(data))
+}
+
+// EvalSource runs the source code like Eval, name is used for it in error
+// messages. Errors in functions it declares show the source of the last
+// call with the same name.
+func (i *Interpreter) EvalSource(name string, source string) error {
+	i.renderer.addSource(name, source)
+	defer i.enter()()
+	i.globals, i.environment = i.scriptGlobals, i.scriptGlobals
+	i.locals = make(map[expression]int)
+	statements, err := i.check(name, source)
+	if err != nil {
+		return err
+	}
+
+	defer i.modules.run(name)()
+	if err := i.interpret(statements); err != nil {
+		var staticErr StaticError
+		if !errors.As(err, &staticErr) {
+			i.renderer.runtimeError(i.stderr, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// check parses and resolves the source, and returns a StaticError if it
+// can't run. The errors are reported to stderr.
+func (i *Interpreter) check(file string, source string) ([]statement, error) {
+	statements, diagnostics := parseSource(file, source)
+
+	// Stop if there was a syntax error.
+	if i.renderer.report(i.stderr, diagnostics) {
+		return nil, newStaticError(diagnostics)
+	}
+
+	resolver := newResolver(i)
+	resolver.resolve(statements)
+
+	// Stop if there was a resolution error.
+	if i.renderer.report(i.stderr, resolver.diagnostics) {
+		return nil, newStaticError(resolver.diagnostics)
+	}
+	return statements, nil
+}
+
+// parseSource scans and parses the source. The diagnostics from both are
+// returned together, in the order they appear in the source.
+func parseSource(file string, source string) ([]statement, []Diagnostic) {
+	scanner := newScanner(file, source)
+	tokens := scanner.scanTokens()
+
+	parser := newParser(tokens)
+	statements, diagnostics := parser.parse()
+
+	diagnostics = append(scanner.diagnostics, diagnostics...)
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].span.start.offset < diagnostics[j].span.start.offset
+	})
+	return statements, diagnostics
+}
+
+// compileSource runs the source through the front end and the bytecode compiler.
+// The function can't be run if any errors are returned.
+func compileSource(file string, source string) (*objFunction, []Diagnostic) {
+	statements, diagnostics := parseSource(file, source)
+	if hasErrors(diagnostics) {
+		return nil, diagnostics
+	}
+
+	// The compiler resolves variables itself, the resolver only checks for static errors
+	resolver := newResolver(nil)
+	resolver.resolve(statements)
+	diagnostics = append(diagnostics, resolver.diagnostics...)
+	if hasErrors(diagnostics) {
+		return nil, diagnostics
+	}
+
+	function, compileDiagnostics := compile(file, statements)
+	return function, append(diagnostics, compileDiagnostics...)
+}
+
+// Get returns the value of the global variable, and false if it isn't defined
+func (i *Interpreter) Get(name string) (any, bool) {
+	value, ok := i.scriptGlobals.values[name]
+	return value, ok
+}
+
+// Set defines the global variable, or assigns it if it is already defined.
+// Go numbers are converted to float64, see toLox for the values Lox can hold.
+func (i *Interpreter) Set(name string, value any) error {
+	loxValue, err := toLox(value)
+	if err != nil {
+		return fmt.Errorf("setting %s: %w", name, err)
+	}
+	i.scriptGlobals.define(name, loxValue)
+	return nil
+}
+
+// CallFunction calls the global function, or class, with the arguments
+// converted like in Set, and returns what it returned. Runtime errors are
+// reported to stderr and returned, like in Eval.
+func (i *Interpreter) CallFunction(name string, args ...any) (any, error) {
+	value, ok := i.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined function '%s'", name)
+	}
+	callable, ok := value.(LoxCallable)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a function", name)
+	}
+	if msg := arityError(callable, len(args)); msg != "" {
+		return nil, fmt.Errorf("calling %s: %s", name, msg)
+	}
+
+	arguments := make([]any, 0, len(args))
+	for n, arg := range args {
+		loxValue, err := toLox(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d to %s: %w", n+1, name, err)
+		}
+		arguments = append(arguments, loxValue)
+	}
+
+	defer i.enter()()
+	result, err := callable.Call(i, arguments)
+	var runtimeErr RuntimeError
+	var budgetErr BudgetError
+	var staticErr StaticError
+	if errors.As(err, &staticErr) {
+		// A module it imported can't run, its errors are already reported
+		return nil, i.unwind(err)
+	} else if errors.As(err, &runtimeErr) || errors.As(err, &budgetErr) {
+		err = i.unwind(err)
+		i.renderer.runtimeError(i.stderr, err)
+		return nil, err
+	} else if err != nil {
+		// A native function failed, there is no Lox code to point at
+		return nil, err
+	}
+	return result, nil
+}
+
+// toLox converts the Go value to the Lox value used for it. Lox values are
+// passed as they are, all Go number types become float64, and types defined
+// on top of bool, string or a number become that. Structs and maps become
+// GoObjects that scripts can use all exported fields and methods of, and
+// slices and arrays become lists. Nil pointers and maps become nil.
+func toLox(value any) (any, error) {
+	switch t := value.(type) {
+	case nil, bool, float64, string, LoxCallable, *LoxInstance, *LoxList, *LoxMap, *LoxError, *LoxModule, *GoObject:
+		return t, nil
+	}
+
+	v := reflect.ValueOf(value)
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Map) && v.IsNil() {
+		// Like a Go function returning a nil *User when there is none
+		return nil, nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		// Copied, so scripts can't change the Go slice
+		elements := make([]any, 0, v.Len())
+		for n := 0; n < v.Len(); n++ {
+			element, err := toLox(v.Index(n).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", n, err)
+			}
+			elements = append(elements, element)
+		}
+		return NewLoxList(elements), nil
+	}
+	if isGoObject(v) {
+		return NewGoObject(value)
+	}
+	return nil, fmt.Errorf("can't use %T as a Lox value", value)
+}
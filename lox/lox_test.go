@@ -0,0 +1,354 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticErrorDiagnostics(t *testing.T) {
+	interpreter := New(WithStderr(io.Discard))
+	err := interpreter.Eval(`{
+  var a = 1;
+  var a = 2;
+}
+return 3;`)
+	var staticErr StaticError
+	if !errors.As(err, &staticErr) {
+		t.Fatalf("expected a StaticError, got %v", err)
+	}
+
+	want := []struct {
+		code   string
+		line   int
+		column int
+	}{
+		{CODE_REDECLARED, 3, 7},
+		{CODE_TOP_LEVEL_RETURN, 5, 1},
+	}
+	diagnostics := staticErr.Diagnostics()
+	if len(diagnostics) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %v", len(diagnostics), len(want), err)
+	}
+	for n, diagnostic := range diagnostics {
+		start := diagnostic.Span().Start()
+		if diagnostic.Code() != want[n].code || start.Line() != want[n].line || start.Column() != want[n].column {
+			t.Errorf("diagnostic %d: got %s at %d:%d, want %s at %d:%d", n,
+				diagnostic.Code(), start.Line(), start.Column(), want[n].code, want[n].line, want[n].column)
+		}
+		if diagnostic.Severity() != SEVERITY_ERROR || diagnostic.Message() == "" || diagnostic.Span().File() != "<eval#1>" {
+			t.Errorf("diagnostic %d: got %v %q in %s", n, diagnostic.Severity(), diagnostic.Message(), diagnostic.Span().File())
+		}
+	}
+}
+
+func TestListAndMapAccessors(t *testing.T) {
+	interpreter := New(WithStderr(io.Discard))
+	if err := interpreter.Eval(`var l = [1, "a", nil]; var m = {"b": 2, 1: true};`); err != nil {
+		t.Fatal(err)
+	}
+
+	value, _ := interpreter.Get("l")
+	list, ok := value.(*LoxList)
+	if !ok {
+		t.Fatalf("got %T, want *LoxList", value)
+	}
+	elements := list.Elements()
+	if list.Len() != 3 || elements[0] != 1.0 || elements[1] != "a" || elements[2] != nil {
+		t.Errorf("got %v", elements)
+	}
+	elements[0] = 5.0
+	if list.Elements()[0] != 1.0 {
+		t.Errorf("changing the elements changed the list: %v", list)
+	}
+
+	value, _ = interpreter.Get("m")
+	m, ok := value.(*LoxMap)
+	if !ok {
+		t.Fatalf("got %T, want *LoxMap", value)
+	}
+	if keys := m.Keys(); m.Len() != 2 || keys[0] != "b" || keys[1] != 1.0 {
+		t.Errorf("got keys %v", keys)
+	}
+	if value, ok := m.Get(1); !ok || value != true {
+		t.Errorf("m[1]: got %v, %v", value, ok)
+	}
+	if _, ok := m.Get("missing"); ok {
+		t.Error("got a value for a missing key")
+	}
+
+	if err := m.Set("c", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set([]int{1}, 3); err == nil {
+		t.Error("Set accepted a list as key")
+	}
+	var out bytes.Buffer
+	interpreter = New(WithStdout(&out), WithStderr(io.Discard))
+	if err := interpreter.Set("m", m); err != nil {
+		t.Fatal(err)
+	}
+	if err := interpreter.Eval(`print m["c"];`); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "3\n" {
+		t.Errorf("got %q, want %q", got, "3\n")
+	}
+}
+
+func TestLocalsDontPileUp(t *testing.T) {
+	var out bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(io.Discard))
+	if err := interpreter.Eval(`fun add(a, b) { var sum = a + b; return sum; }`); err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < 100; n++ {
+		if err := interpreter.Eval(`{ var a = 1; var b = a; print add(a, b); }`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(interpreter.locals); got > 10 {
+		t.Errorf("the interpreter holds %d resolved locals after 100 evaluations", got)
+	}
+	if _, err := interpreter.CallFunction("add", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvalKeepsGlobals(t *testing.T) {
+	var out bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(io.Discard))
+	if err := interpreter.Eval(`var count = 1;`); err != nil {
+		t.Fatal(err)
+	}
+	if err := interpreter.Eval(`count = count + 1; print count;`); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "2\n" {
+		t.Errorf("got %q, want %q", got, "2\n")
+	}
+	if value, ok := interpreter.Get("count"); !ok || value != 2.0 {
+		t.Errorf("Get: got %v, %v", value, ok)
+	}
+	if _, ok := interpreter.Get("missing"); ok {
+		t.Error("Get found an undefined global")
+	}
+}
+
+func TestEvalFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"script.lox": `print "from a file";`})
+
+	var out bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(io.Discard))
+	if err := interpreter.EvalFile(filepath.Join(dir, "script.lox")); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "from a file\n" {
+		t.Errorf("got %q, want %q", got, "from a file\n")
+	}
+	if err := interpreter.EvalFile(filepath.Join(dir, "missing.lox")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v, want a not exist error", err)
+	}
+}
+
+func TestErrorsGoToStderr(t *testing.T) {
+	var out, stderr bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(&stderr))
+	err := interpreter.Eval(`print "before"; print 1 - "a";`)
+	if msg := runtimeMessage(t, err); msg != "Operand must be a number" {
+		t.Errorf("got %q", msg)
+	}
+	if got := out.String(); got != "before\n" {
+		t.Errorf("stdout: got %q, want %q", got, "before\n")
+	}
+	if !strings.Contains(stderr.String(), "Operand must be a number") {
+		t.Errorf("stderr: got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	err = interpreter.Eval(`print ;`)
+	var staticErr StaticError
+	if !errors.As(err, &staticErr) {
+		t.Fatalf("expected a StaticError, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), staticErr.Diagnostics()[0].Message()) {
+		t.Errorf("stderr: got %q", stderr.String())
+	}
+}
+
+func TestSet(t *testing.T) {
+	type celsius float32
+	tests := []struct {
+		value any
+		want  any
+	}{
+		{nil, nil},
+		{true, true},
+		{3, 3.0},
+		{uint8(7), 7.0},
+		{celsius(1.5), 1.5},
+		{"text", "text"},
+		{[]string{"a", "b"}, `["a", "b"]`},
+	}
+	for _, test := range tests {
+		interpreter := New(WithStderr(io.Discard))
+		if err := interpreter.Set("v", test.value); err != nil {
+			t.Errorf("Set(%#v): %v", test.value, err)
+			continue
+		}
+		value, _ := interpreter.Get("v")
+		if list, ok := value.(*LoxList); ok {
+			value = list.String()
+		}
+		if value != test.want {
+			t.Errorf("Set(%#v): got %#v, want %#v", test.value, value, test.want)
+		}
+	}
+
+	interpreter := New(WithStderr(io.Discard))
+	if err := interpreter.Set("ch", make(chan int)); err == nil {
+		t.Error("Set accepted a channel")
+	}
+}
+
+func TestCallFunction(t *testing.T) {
+	interpreter := New(WithStderr(io.Discard))
+	err := interpreter.Eval(`
+fun add(a, b) { return a + b; }
+fun fail() { return nil - 1; }
+class Point { init(x) { this.x = x; } }
+var notAFunction = 1;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sum, err := interpreter.CallFunction("add", 1, 2); err != nil || sum != 3.0 {
+		t.Errorf("add: got %v, %v", sum, err)
+	}
+	point, err := interpreter.CallFunction("Point", 5)
+	if instance, ok := point.(*LoxInstance); err != nil || !ok || instance.String() != "Point instance" {
+		t.Errorf("Point: got %v, %v", point, err)
+	}
+
+	errorTests := []struct {
+		name string
+		args []any
+		want string
+	}{
+		{"missing", nil, "undefined function 'missing'"},
+		{"notAFunction", nil, "'notAFunction' is not a function"},
+		{"add", []any{1}, "calling add: Expected 2 arguments but got 1."},
+		{"add", []any{1, make(chan int)}, "argument 2 to add: can't use chan int as a Lox value"},
+	}
+	for _, test := range errorTests {
+		_, err := interpreter.CallFunction(test.name, test.args...)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("%s%v: got %v, want %q", test.name, test.args, err, test.want)
+		}
+	}
+
+	_, err = interpreter.CallFunction("fail")
+	if msg := runtimeMessage(t, err); msg != "Operand must be a number" {
+		t.Errorf("fail: got %q", msg)
+	}
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	// Calls nested in loops and expressions take the most Go stack, the
+	// deepest recursion allowed must still stop with a runtime error
+	interpreter := New(WithStderr(io.Discard), WithMaxDepth(MAX_DEPTH_LIMIT))
+	err := interpreter.Eval(`
+fun f(n) {
+  while (true) {
+    for (var i = 0; i < 1; i = i + 1) {
+      try {
+        return 1 + (1 + (1 + [f(n + 1)][0]));
+      } finally {}
+    }
+  }
+}
+f(0);`)
+	if msg := runtimeMessage(t, err); msg != "Stack overflow." {
+		t.Errorf("got %q", msg)
+	}
+
+	// Out of range depths are clamped
+	for depth, want := range map[int]int{-1: 1, 0: 1, MAX_DEPTH_LIMIT + 1: MAX_DEPTH_LIMIT} {
+		if got := New(WithMaxDepth(depth)).maxDepth; got != want {
+			t.Errorf("WithMaxDepth(%d): got max depth %d, want %d", depth, got, want)
+		}
+	}
+}
+
+func TestEvalSourcesAreKept(t *testing.T) {
+	var stderr bytes.Buffer
+	interpreter := New(WithStderr(&stderr))
+	if err := interpreter.Eval("fun f() {\n  return nil - 1;\n}"); err != nil {
+		t.Fatal(err)
+	}
+	err := interpreter.Eval("print \"calling\";\nf();")
+	if err == nil || !strings.HasPrefix(err.Error(), "<eval#1>:2:") {
+		t.Errorf("got %v, want the error in the first Eval", err)
+	}
+	if !strings.Contains(stderr.String(), "2 |   return nil - 1;") {
+		t.Errorf("stderr doesn't show the line of the error: %s", stderr.String())
+	}
+}
+
+func TestNestedEval(t *testing.T) {
+	var out bytes.Buffer
+	var interpreter *Interpreter
+	interpreter = newWithNatives(t, &out, map[string]any{
+		"inner": func(source string) error { return interpreter.Eval(source) },
+	})
+	err := interpreter.Eval(`
+{
+  var a = 1;
+  inner("var z = 2;");
+  print a;
+}
+print z;
+fun f() {
+  var b = 3;
+  inner("print z + 1;");
+  return b;
+}
+print f();`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "1\n2\n3\n3\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// The calls of a failing nested Eval are cleared with the outer ones
+	err = interpreter.Eval(`
+fun g() {
+  inner("nil - 1;");
+}
+g();`)
+	if msg := runtimeMessage(t, err); msg != "Operand must be a number" {
+		t.Errorf("got %q", msg)
+	}
+	if interpreter.frames.Len() != 0 || interpreter.depth != 0 {
+		t.Errorf("got %d frames at depth %d after the error", interpreter.frames.Len(), interpreter.depth)
+	}
+
+	// Nested calls share the budget of the outermost one
+	interpreter = newWithNatives(t, io.Discard, map[string]any{
+		"inner": func(source string) error { return interpreter.Eval(source) },
+	})
+	WithMaxSteps(1000)(interpreter)
+	err = interpreter.Eval(`
+for (var i = 0; i < 100; i = i + 1) {
+  inner("for (var j = 0; j < 100; j = j + 1) {}");
+}`)
+	if !errors.Is(err, ErrStepLimit) {
+		t.Errorf("got %v, want the step limit", err)
+	}
+}
@@ -0,0 +1,480 @@
+package lox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// A .loxc file holds a compiled script, so it can be run without scanning,
+// parsing and compiling it again. All integers are big endian.
+//
+//	magic        "LOXC"
+//	version      uint16
+//	string table uint32 count, then every string as uint32 length + bytes
+//	function     the top-level script, see writeFunction
+//	checksum     uint32 CRC-32 (IEEE) of everything before it
+//
+// Strings, both names and string constants, are stored once in the string
+// table and referred to by their uint32 index everywhere else.
+//
+// Files are checked before they are loaded: the checksum catches files that
+// were damaged, and verifyFunction bytecode that the compiler would never
+// have written. verifyFunction doesn't follow the stack though, so bytecode
+// that pops more than it pushed is only caught by the VM when it runs it.
+
+var loxcMagic = [4]byte{'L', 'O', 'X', 'C'}
+
+// LOXC_VERSION must be bumped whenever the layout, or the meaning of the
+// bytecode (opcodes, operands), changes.
+const LOXC_VERSION uint16 = 8
+
+// Tags for the entries in a constant pool
+const (
+	loxcConstantNumber = byte(iota)
+	loxcConstantString
+	loxcConstantFunction
+)
+
+// maxLoxcLength guards against allocating huge buffers for corrupt files
+const maxLoxcLength = 1 << 24
+
+var (
+	ErrLoxcMagic    = errors.New("not a .loxc file")
+	ErrLoxcVersion  = errors.New("unsupported .loxc version")
+	ErrLoxcChecksum = errors.New("corrupt .loxc file, the checksum doesn't match")
+	ErrLoxcInvalid  = errors.New("invalid bytecode")
+)
+
+type loxcWriter struct {
+	w       *bufio.Writer
+	strings map[string]uint32
+	order   []string
+	err     error
+}
+
+// writeLoxc serializes the compiled script
+func writeLoxc(w io.Writer, function *objFunction) error {
+	checksum := crc32.NewIEEE()
+	lw := &loxcWriter{
+		w:       bufio.NewWriter(io.MultiWriter(w, checksum)),
+		strings: make(map[string]uint32),
+	}
+	lw.collectStrings(function)
+
+	lw.write(loxcMagic)
+	lw.write(LOXC_VERSION)
+
+	lw.write(uint32(len(lw.order)))
+	for _, s := range lw.order {
+		lw.write(uint32(len(s)))
+		lw.writeBytes([]byte(s))
+	}
+
+	lw.writeFunction(function)
+
+	if lw.err != nil {
+		return lw.err
+	}
+	if err := lw.w.Flush(); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, checksum.Sum32())
+}
+
+// collectStrings builds the string table, in the order the strings are
+// first seen, so the same script always gives the same file
+func (lw *loxcWriter) collectStrings(function *objFunction) {
+	lw.addString(function.name)
+	lw.addString(function.chunk.file)
+	for _, constant := range function.chunk.constants {
+		switch c := constant.(type) {
+		case string:
+			lw.addString(c)
+		case *objFunction:
+			lw.collectStrings(c)
+		}
+	}
+}
+
+func (lw *loxcWriter) addString(s string) {
+	if _, ok := lw.strings[s]; ok {
+		return
+	}
+	lw.strings[s] = uint32(len(lw.order))
+	lw.order = append(lw.order, s)
+}
+
+func (lw *loxcWriter) write(data any) {
+	if lw.err != nil {
+		return
+	}
+	lw.err = binary.Write(lw.w, binary.BigEndian, data)
+}
+
+func (lw *loxcWriter) writeBytes(b []byte) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = lw.w.Write(b)
+}
+
+// writeFunction writes a function prototype:
+//
+//	name          uint32 string index
+//	file          uint32 string index of the script the function is in
+//	arity         uint8
+//	upvalues      uint16 count
+//	code          uint32 length + bytes
+//	line table    uint32 count of runs, then (line, column, offset, length)
+//	              as uint32s per run
+//	constants     uint32 count, then a tag byte and the value per constant.
+//	              Numbers are float64 bits, strings a uint32 string index,
+//	              functions a nested prototype.
+func (lw *loxcWriter) writeFunction(function *objFunction) {
+	chunk := function.chunk
+
+	lw.write(lw.strings[function.name])
+	lw.write(lw.strings[chunk.file])
+	lw.write(uint8(function.arity))
+	lw.write(uint16(function.upvalueCount))
+
+	lw.write(uint32(len(chunk.code)))
+	lw.writeBytes(chunk.code)
+
+	// Positions are run-length encoded, all the bytes of an instruction share one
+	type run struct {
+		position Position
+		length   uint32
+	}
+	var runs []run
+	for _, position := range chunk.positions {
+		if len(runs) > 0 && runs[len(runs)-1].position == position {
+			runs[len(runs)-1].length++
+			continue
+		}
+		runs = append(runs, run{position: position, length: 1})
+	}
+	lw.write(uint32(len(runs)))
+	for _, r := range runs {
+		lw.write(uint32(r.position.line))
+		lw.write(uint32(r.position.column))
+		lw.write(uint32(r.position.offset))
+		lw.write(r.length)
+	}
+
+	lw.write(uint32(len(chunk.constants)))
+	for _, constant := range chunk.constants {
+		switch c := constant.(type) {
+		case float64:
+			lw.write(loxcConstantNumber)
+			lw.write(math.Float64bits(c))
+		case string:
+			lw.write(loxcConstantString)
+			lw.write(lw.strings[c])
+		case *objFunction:
+			lw.write(loxcConstantFunction)
+			lw.writeFunction(c)
+		default:
+			if lw.err == nil {
+				lw.err = fmt.Errorf("can't serialize constant %[1]v of type %[1]T", constant)
+			}
+		}
+	}
+}
+
+type loxcReader struct {
+	r       *bytes.Reader
+	strings []string
+	err     error
+}
+
+// readLoxc loads a compiled script written by writeLoxc
+func readLoxc(r io.Reader) (*objFunction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	lr := &loxcReader{
+		r: bytes.NewReader(data),
+	}
+
+	var magic [4]byte
+	lr.read(&magic)
+	if lr.err != nil {
+		return nil, fmt.Errorf("reading header: %w", lr.err)
+	}
+	if magic != loxcMagic {
+		return nil, ErrLoxcMagic
+	}
+
+	var version uint16
+	lr.read(&version)
+	if lr.err != nil {
+		return nil, fmt.Errorf("reading header: %w", lr.err)
+	}
+	if version != LOXC_VERSION {
+		return nil, fmt.Errorf("%w %d, expected %d", ErrLoxcVersion, version, LOXC_VERSION)
+	}
+
+	// Checked after the version, files from other versions may not have one
+	header := len(data) - lr.r.Len()
+	if len(data)-header < 4 {
+		return nil, ErrLoxcChecksum
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[len(body):]) {
+		return nil, ErrLoxcChecksum
+	}
+	lr.r = bytes.NewReader(body[header:])
+
+	count := lr.readLength()
+	for i := uint32(0); i < count && lr.err == nil; i++ {
+		lr.strings = append(lr.strings, string(lr.readBytes(lr.readLength())))
+	}
+
+	function := lr.readFunction()
+	if lr.err != nil {
+		return nil, fmt.Errorf("reading function: %w", lr.err)
+	}
+	if lr.r.Len() > 0 {
+		return nil, fmt.Errorf("reading function: %d bytes left after it", lr.r.Len())
+	}
+	if err := verifyFunction(function); err != nil {
+		return nil, err
+	}
+	return function, nil
+}
+
+func (lr *loxcReader) read(data any) {
+	if lr.err != nil {
+		return
+	}
+	lr.err = binary.Read(lr.r, binary.BigEndian, data)
+}
+
+func (lr *loxcReader) readLength() uint32 {
+	var length uint32
+	lr.read(&length)
+	if lr.err == nil && length > maxLoxcLength {
+		lr.err = fmt.Errorf("length %d is too large", length)
+	}
+	if lr.err != nil {
+		return 0
+	}
+	return length
+}
+
+func (lr *loxcReader) readBytes(length uint32) []byte {
+	if lr.err != nil {
+		return nil
+	}
+	b := make([]byte, length)
+	_, lr.err = io.ReadFull(lr.r, b)
+	return b
+}
+
+func (lr *loxcReader) readString() string {
+	var index uint32
+	lr.read(&index)
+	if lr.err != nil {
+		return ""
+	}
+	if int(index) >= len(lr.strings) {
+		lr.err = fmt.Errorf("string index %d out of range", index)
+		return ""
+	}
+	return lr.strings[index]
+}
+
+func (lr *loxcReader) readFunction() *objFunction {
+	function := newObjFunction()
+	chunk := function.chunk
+
+	function.name = lr.readString()
+	chunk.file = lr.readString()
+
+	var arity uint8
+	lr.read(&arity)
+	function.arity = int(arity)
+
+	var upvalueCount uint16
+	lr.read(&upvalueCount)
+	function.upvalueCount = int(upvalueCount)
+
+	chunk.code = lr.readBytes(lr.readLength())
+
+	runs := lr.readLength()
+	for i := uint32(0); i < runs && lr.err == nil; i++ {
+		var line, column, offset, length uint32
+		lr.read(&line)
+		lr.read(&column)
+		lr.read(&offset)
+		lr.read(&length)
+		if lr.err == nil && len(chunk.positions)+int(length) > len(chunk.code) {
+			lr.err = fmt.Errorf("line table covers more than the %d bytes of code", len(chunk.code))
+		}
+		position := Position{line: int(line), column: int(column), offset: int(offset)}
+		for j := uint32(0); j < length && lr.err == nil; j++ {
+			chunk.positions = append(chunk.positions, position)
+		}
+	}
+	if lr.err == nil && len(chunk.positions) != len(chunk.code) {
+		lr.err = fmt.Errorf("line table covers %d bytes, but there are %d bytes of code",
+			len(chunk.positions), len(chunk.code))
+	}
+
+	constants := lr.readLength()
+	for i := uint32(0); i < constants && lr.err == nil; i++ {
+		var tag byte
+		lr.read(&tag)
+		switch tag {
+		case loxcConstantNumber:
+			var bits uint64
+			lr.read(&bits)
+			chunk.constants = append(chunk.constants, math.Float64frombits(bits))
+		case loxcConstantString:
+			chunk.constants = append(chunk.constants, lr.readString())
+		case loxcConstantFunction:
+			chunk.constants = append(chunk.constants, lr.readFunction())
+		default:
+			if lr.err == nil {
+				lr.err = fmt.Errorf("unknown constant tag %d", tag)
+			}
+		}
+	}
+
+	return function
+}
+
+// verifyFunction checks the bytecode of the function, and of the functions
+// declared in it, the way the compiler writes it: every opcode is known,
+// operands are within the code, constants and upvalues exist and have the
+// right type, jumps land on an instruction, and the code can't run off its
+// end. Local slots are not checked, that needs the height of the stack.
+func verifyFunction(function *objFunction) error {
+	chunk := function.chunk
+	invalid := func(offset int, format string, args ...any) error {
+		return fmt.Errorf("%w in %v at offset %d: %s", ErrLoxcInvalid, function, offset, fmt.Sprintf(format, args...))
+	}
+	if len(chunk.code) == 0 {
+		return invalid(0, "there is no code")
+	}
+
+	starts := make([]bool, len(chunk.code))
+	// jumps holds the offset of every jump, and targets where it goes
+	var jumps, targets []int
+	var op opCode
+	for offset := 0; offset < len(chunk.code); {
+		starts[offset] = true
+		op = opCode(chunk.code[offset])
+		size, ok := operandSize(op)
+		if !ok {
+			return invalid(offset, "unknown opcode %d", op)
+		}
+		if offset+1+size > len(chunk.code) {
+			return invalid(offset, "the operands of %v are cut off", op)
+		}
+
+		switch op {
+		case opConstant:
+			if index := readShortAt(chunk, offset+1); index >= len(chunk.constants) {
+				return invalid(offset, "constant %d doesn't exist", index)
+			}
+		case opGetGlobal, opDefineGlobal, opSetGlobal,
+			opGetProperty, opSetProperty, opGetSuper,
+			opClass, opInherit, opMethod, opImport:
+			index := readShortAt(chunk, offset+1)
+			if index >= len(chunk.constants) {
+				return invalid(offset, "constant %d doesn't exist", index)
+			}
+			if _, ok := chunk.constants[index].(string); !ok {
+				return invalid(offset, "%v needs a string constant", op)
+			}
+		case opGetUpvalue, opSetUpvalue:
+			if index := int(chunk.code[offset+1]); index >= function.upvalueCount {
+				return invalid(offset, "upvalue %d doesn't exist", index)
+			}
+		case opJump, opJumpIfFalse, opTry:
+			jumps = append(jumps, offset)
+			targets = append(targets, offset+3+readShortAt(chunk, offset+1))
+		case opLoop:
+			jumps = append(jumps, offset)
+			targets = append(targets, offset+3-readShortAt(chunk, offset+1))
+		case opClosure:
+			index := readShortAt(chunk, offset+1)
+			if index >= len(chunk.constants) {
+				return invalid(offset, "constant %d doesn't exist", index)
+			}
+			nested, ok := chunk.constants[index].(*objFunction)
+			if !ok {
+				return invalid(offset, "%v needs a function constant", op)
+			}
+			// The (isLocal, index) pair of every upvalue the function captures
+			captures := offset + 3
+			if captures+2*nested.upvalueCount > len(chunk.code) {
+				return invalid(offset, "the upvalues of %v are cut off", nested)
+			}
+			for n := 0; n < nested.upvalueCount; n++ {
+				isLocal, index := chunk.code[captures+2*n], int(chunk.code[captures+2*n+1])
+				if isLocal > 1 {
+					return invalid(offset, "upvalue %d of %v is neither local nor an upvalue", n, nested)
+				}
+				if isLocal == 0 && index >= function.upvalueCount {
+					return invalid(offset, "upvalue %d doesn't exist", index)
+				}
+			}
+			size += 2 * nested.upvalueCount
+		}
+		offset += 1 + size
+	}
+
+	switch op {
+	case opReturn, opJump, opLoop, opThrow:
+	default:
+		return invalid(len(chunk.code)-1, "the code runs off its end after %v", op)
+	}
+	for n, target := range targets {
+		if target < 0 || target >= len(chunk.code) || !starts[target] {
+			return invalid(jumps[n], "%v jumps to %d, which is not an instruction", opCode(chunk.code[jumps[n]]), target)
+		}
+	}
+
+	for _, constant := range chunk.constants {
+		if nested, ok := constant.(*objFunction); ok {
+			if err := verifyFunction(nested); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// operandSize returns the number of bytes of operands after the opcode, not
+// counting the upvalues after OP_CLOSURE, and false for unknown opcodes
+func operandSize(op opCode) (int, bool) {
+	switch op {
+	case opConstant, opGetGlobal, opDefineGlobal, opSetGlobal,
+		opGetProperty, opSetProperty, opGetSuper,
+		opClass, opInherit, opMethod, opImport,
+		opJump, opJumpIfFalse, opTry, opLoop,
+		opClosure, opList, opMap, opInterpolate:
+		return 2, true
+	case opGetLocal, opSetLocal, opGetUpvalue, opSetUpvalue, opCall:
+		return 1, true
+	case opNil, opTrue, opFalse, opPop,
+		opEqual, opNotEqual, opGreater, opGreaterEqual, opLess, opLessEqual,
+		opAdd, opSubtract, opMultiply, opDivide, opNot, opNegate,
+		opPrint, opCloseUpvalue, opReturn,
+		opGetIndex, opSetIndex,
+		opEndTry, opCatch, opThrow:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
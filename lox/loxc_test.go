@@ -0,0 +1,156 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+const loxcScript = `
+fun makeCounter() {
+  var i = 0;
+  fun count() { i = i + 1; return i; }
+  return count;
+}
+class A { greet() { return "hi"; } }
+var counter = makeCounter();
+for (var n = 0; n < 3; n = n + 1) {
+  try { counter(); } catch (e) { print e; }
+}
+print "a" + A().greet();`
+
+// writeScript compiles the source and returns it as a .loxc file
+func writeScript(t *testing.T, source string) []byte {
+	t.Helper()
+	function, diagnostics := compileSource("script.lox", source)
+	if hasErrors(diagnostics) {
+		t.Fatalf("compiling: %v", diagnostics)
+	}
+	var buf bytes.Buffer
+	if err := writeLoxc(&buf, function); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoxcRoundTrip(t *testing.T) {
+	function, _ := compileSource("script.lox", loxcScript)
+	loaded, err := readLoxc(bytes.NewReader(writeScript(t, loxcScript)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got strings.Builder
+	disassembleFunction(&want, function)
+	disassembleFunction(&got, loaded)
+	if got.String() != want.String() {
+		t.Errorf("got\n%s\nwant\n%s", got.String(), want.String())
+	}
+}
+
+func TestLoxcCorrupt(t *testing.T) {
+	data := writeScript(t, loxcScript)
+	for n := range data {
+		for _, flip := range []byte{0x01, 0x80, 0xff} {
+			corrupt := append([]byte(nil), data...)
+			corrupt[n] ^= flip
+			if _, err := readLoxc(bytes.NewReader(corrupt)); err == nil {
+				t.Fatalf("flipping byte %d with %#x wasn't noticed", n, flip)
+			}
+		}
+	}
+	for n := 0; n < len(data); n++ {
+		if _, err := readLoxc(bytes.NewReader(data[:n])); err == nil {
+			t.Fatalf("cutting the file at %d bytes wasn't noticed", n)
+		}
+	}
+}
+
+func TestLoxcInvalidBytecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      []byte
+		constants []any
+		upvalues  int
+		want      string
+	}{
+		{"unknown opcode", []byte{255, byte(opReturn)}, nil, 0, "unknown opcode 255"},
+		{"cut off operand", []byte{byte(opNil), byte(opReturn), byte(opConstant), 0}, nil, 0, "operands of OP_CONSTANT are cut off"},
+		{"missing constant", []byte{byte(opConstant), 0, 1, byte(opReturn)}, []any{1.0}, 0, "constant 1 doesn't exist"},
+		{"not a string", []byte{byte(opGetGlobal), 0, 0, byte(opReturn)}, []any{1.0}, 0, "OP_GET_GLOBAL needs a string constant"},
+		{"not a function", []byte{byte(opClosure), 0, 0, byte(opReturn)}, []any{"f"}, 0, "OP_CLOSURE needs a function constant"},
+		{"missing upvalue", []byte{byte(opGetUpvalue), 1, byte(opReturn)}, nil, 1, "upvalue 1 doesn't exist"},
+		{"jump past the end", []byte{byte(opJump), 0, 1, byte(opReturn)}, nil, 0, "OP_JUMP jumps to 4"},
+		{"jump into an operand", []byte{byte(opLoop), 0, 2, byte(opReturn)}, nil, 0, "OP_LOOP jumps to 1"},
+		{"no return", []byte{byte(opNil), byte(opPrint)}, nil, 0, "runs off its end"},
+		{"no code", nil, nil, 0, "there is no code"},
+	}
+	for _, test := range tests {
+		function := newObjFunction()
+		function.upvalueCount = test.upvalues
+		function.chunk.code = test.code
+		function.chunk.positions = make([]Position, len(test.code))
+		function.chunk.constants = test.constants
+		var buf bytes.Buffer
+		if err := writeLoxc(&buf, function); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := readLoxc(&buf)
+		if !errors.Is(err, ErrLoxcInvalid) || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want it to contain %q", test.name, err, test.want)
+		}
+	}
+
+	// The stack isn't checked when loading, the VM catches it when running
+	function := newObjFunction()
+	function.chunk.code = []byte{byte(opPop), byte(opPop), byte(opReturn)}
+	function.chunk.positions = make([]Position, len(function.chunk.code))
+	var buf bytes.Buffer
+	if err := writeLoxc(&buf, function); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := readLoxc(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = newVM(DEFAULT_MAX_DEPTH).interpret(loaded)
+	if !errors.Is(err, ErrLoxcInvalid) || !strings.Contains(err.Error(), "index out of range") {
+		t.Errorf("popping an empty stack: got %v", err)
+	}
+}
+
+func TestProgram(t *testing.T) {
+	var out bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(io.Discard))
+	if err := interpreter.RegisterFunction("double", func(n int) int { return 2 * n }); err != nil {
+		t.Fatal(err)
+	}
+	program, err := interpreter.Compile("script.lox", loxcScript+"\nprint double(21);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := program.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadProgram(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := interpreter.Run(loaded); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "ahi\n42\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := interpreter.Compile("broken.lox", "print ;"); err == nil {
+		t.Error("compiling a syntax error didn't fail")
+	}
+	budgeted := New(WithStderr(io.Discard), WithMaxSteps(100))
+	if err := budgeted.Run(program); err == nil {
+		t.Error("Run ignored the execution budget")
+	}
+}
@@ -0,0 +1,29 @@
+package lox
+
+import "fmt"
+
+type LoxCallable interface {
+	Arity() int
+	Call(interpreter *Interpreter, arguments []any) (any, error)
+}
+
+// variadicCallable is a LoxCallable that takes Arity() or more arguments
+type variadicCallable interface {
+	LoxCallable
+	isVariadic() bool
+}
+
+// arityError returns the error message for calling the callable with count
+// arguments, or "" if that is the right number
+func arityError(callable LoxCallable, count int) string {
+	if variadic, ok := callable.(variadicCallable); ok && variadic.isVariadic() {
+		if count < callable.Arity() {
+			return fmt.Sprintf("Expected at least %d arguments but got %d.", callable.Arity(), count)
+		}
+		return ""
+	}
+	if count != callable.Arity() {
+		return fmt.Sprintf("Expected %d arguments but got %d.", callable.Arity(), count)
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package lox
+
+// https://craftinginterpreters.com/classes.html#class-declarations
+type LoxClass struct {
+	name       string
+	superclass *LoxClass
+	methods    map[string]*LoxFunction
+}
+
+// Type check, just to be safe
+var _ LoxCallable = &LoxClass{}
+
+func newLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
+	return &LoxClass{
+		name:       name,
+		superclass: superclass,
+		methods:    methods,
+	}
+}
+
+// findMethod looks up a method on the class, and then up the superclass chain
+// https://craftinginterpreters.com/inheritance.html#inheriting-methods
+func (c *LoxClass) findMethod(name string) *LoxFunction {
+	if method, ok := c.methods[name]; ok {
+		return method
+	}
+
+	if c.superclass != nil {
+		return c.superclass.findMethod(name)
+	}
+
+	return nil
+}
+
+// Call creates a new instance of the class, and runs the
+// initializer on it if there is one
+func (c *LoxClass) Call(interpreter *Interpreter, arguments []any) (any, error) {
+	instance := newLoxInstance(c)
+	if initializer := c.findMethod("init"); initializer != nil {
+		if _, err := initializer.bind(instance).Call(interpreter, arguments); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+// Arity is the arity of the initializer, or zero if there is none
+func (c *LoxClass) Arity() int {
+	if initializer := c.findMethod("init"); initializer != nil {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (c *LoxClass) String() string {
+	return c.name
+}
@@ -0,0 +1,93 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LoxError is the value a catch clause gets for a runtime error raised by the
+// interpreter, like dividing by zero or calling a function with the wrong
+// number of arguments. Scripts can read its message, line and stack, which
+// are read only. Values thrown with a throw statement are caught as they are.
+type LoxError struct {
+	// err is the error that was caught, throwing the LoxError again raises
+	// it as it was, at the place where it first happened
+	err RuntimeError
+	// stack holds a line for every call in progress, innermost first
+	stack *LoxList
+}
+
+// newLoxError returns the value scripts catch for the error. The trace of the
+// error must already be filled in.
+func newLoxError(err RuntimeError) *LoxError {
+	stack := make([]any, 0, len(err.trace))
+	for _, frame := range err.trace {
+		stack = append(stack, frame.String())
+	}
+	return &LoxError{err: err, stack: NewLoxList(stack)}
+}
+
+// property returns the field with the name, and false if there is no such field
+func (e *LoxError) property(name string) (any, bool) {
+	switch name {
+	case "message":
+		return e.err.msg, true
+	case "line":
+		return float64(e.err.token.line), true
+	case "stack":
+		return e.stack, true
+	default:
+		return nil, false
+	}
+}
+
+func (e *LoxError) get(name token) (any, error) {
+	if value, ok := e.property(name.lexeme); ok {
+		return value, nil
+	}
+	return nil, RuntimeError{
+		token: name,
+		msg:   fmt.Sprintf("Undefined property '%s'.", name.lexeme),
+	}
+}
+
+func (e *LoxError) String() string {
+	return "<error " + e.err.msg + ">"
+}
+
+// caughtValue returns the value a catch clause gets for the error
+func caughtValue(err RuntimeError) any {
+	if err.thrown {
+		return err.value
+	}
+	return newLoxError(err)
+}
+
+// throwError returns the error raised by throwing the value at the throw
+// statement. Throwing a caught LoxError raises the original error again.
+func throwError(keyword token, value any) RuntimeError {
+	if loxError, ok := value.(*LoxError); ok {
+		return loxError.err
+	}
+	return RuntimeError{
+		token:  keyword,
+		msg:    stringify(value),
+		thrown: true,
+		value:  value,
+	}
+}
+
+// isCatchable reports whether a try statement can catch the error, and
+// returns the RuntimeError in it. BudgetErrors can't be caught, a script must
+// not be able to keep itself running after the host stopped it.
+func isCatchable(err error) (RuntimeError, bool) {
+	var budgetErr BudgetError
+	if errors.As(err, &budgetErr) {
+		return RuntimeError{}, false
+	}
+	var runtimeErr RuntimeError
+	if errors.As(err, &runtimeErr) {
+		return runtimeErr, true
+	}
+	return RuntimeError{}, false
+}
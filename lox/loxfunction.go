@@ -0,0 +1,91 @@
+package lox
+
+import (
+	"fmt"
+)
+
+type LoxFunction struct {
+	declaration functionStmt
+	closure     *environment
+	// globals holds the globals of the script or module the function was
+	// declared in, which it sees wherever it is called from
+	globals *environment
+	// locals holds the scope depths the resolver found for the code the
+	// function was declared in, see Interpreter.locals
+	locals        map[expression]int
+	isInitializer bool
+}
+
+// Type check, just to be safe
+var _ LoxCallable = &LoxFunction{}
+
+func newLoxFunction(declaration functionStmt, closure *environment, globals *environment, locals map[expression]int, isInitializer bool) *LoxFunction {
+	return &LoxFunction{
+		closure:       closure,
+		globals:       globals,
+		locals:        locals,
+		declaration:   declaration,
+		isInitializer: isInitializer,
+	}
+}
+
+// bind creates a copy of the method with "this" defined in a new
+// environment wrapping the closure
+// https://craftinginterpreters.com/classes.html#this
+func (l *LoxFunction) bind(instance *LoxInstance) *LoxFunction {
+	environment := newEnvironment(l.closure)
+	environment.define("this", instance)
+	return newLoxFunction(l.declaration, environment, l.globals, l.locals, l.isInitializer)
+}
+
+func (l *LoxFunction) Call(interpreter *Interpreter, arguments []any) (any, error) {
+	if interpreter.depth >= interpreter.maxDepth {
+		// Point at the call that was one too many, if it came from Lox code
+		token := l.declaration.name
+		if frame, ok := interpreter.frames.Peek(); ok {
+			token = frame.callSite
+		}
+		return nil, RuntimeError{
+			token: token,
+			msg:   "Stack overflow.",
+		}
+	}
+	interpreter.depth++
+	globals, locals := interpreter.globals, interpreter.locals
+	interpreter.globals, interpreter.locals = l.globals, l.locals
+	defer func() {
+		interpreter.depth--
+		interpreter.globals, interpreter.locals = globals, locals
+	}()
+
+	environment := newEnvironment(l.closure)
+	for i := 0; i < len(l.declaration.params); i++ {
+		environment.define(
+			l.declaration.params[i].lexeme,
+			arguments[i],
+		)
+	}
+
+	completion, err := interpreter.executeBlock(l.declaration.body, environment)
+	if err != nil {
+		return nil, fmt.Errorf("executing block: %w", err)
+	}
+
+	// An initializer always returns "this", even on an early "return;"
+	if l.isInitializer {
+		return l.closure.getAt(0, "this"), nil
+	}
+
+	if completion.completionType == completionReturn {
+		return completion.value, nil
+	}
+	return nil, nil
+}
+
+func (l *LoxFunction) Arity() int {
+	return len(l.declaration.params)
+}
+
+func (l *LoxFunction) String() string {
+	return "<fn " + l.declaration.name.lexeme + ">"
+}
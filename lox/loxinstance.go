@@ -0,0 +1,43 @@
+package lox
+
+import (
+	"fmt"
+)
+
+// https://craftinginterpreters.com/classes.html#creating-instances
+type LoxInstance struct {
+	class  *LoxClass
+	fields map[string]any
+}
+
+func newLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{
+		class:  class,
+		fields: make(map[string]any),
+	}
+}
+
+// get looks up a property on the instance. Fields shadow methods,
+// and methods are bound to the instance so "this" works inside them.
+func (l *LoxInstance) get(name token) (any, error) {
+	if value, ok := l.fields[name.lexeme]; ok {
+		return value, nil
+	}
+
+	if method := l.class.findMethod(name.lexeme); method != nil {
+		return method.bind(l), nil
+	}
+
+	return nil, RuntimeError{
+		token: name,
+		msg:   fmt.Sprintf("Undefined property '%s'.", name.lexeme),
+	}
+}
+
+func (l *LoxInstance) set(name token, value any) {
+	l.fields[name.lexeme] = value
+}
+
+func (l *LoxInstance) String() string {
+	return l.class.name + " instance"
+}
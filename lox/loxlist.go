@@ -0,0 +1,175 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoxList is the list type, created with a list literal like [1, 2, 3].
+// Lists are mutable and passed by reference, like instances.
+type LoxList struct {
+	elements []any
+}
+
+// NewLoxList returns a list of the elements, which must be Lox values. The
+// list uses the slice, it isn't copied.
+func NewLoxList(elements []any) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+// Len returns the number of elements in the list
+func (l *LoxList) Len() int {
+	return len(l.elements)
+}
+
+// Elements returns a copy of the elements of the list, so hosts can read them
+// without scripts seeing later changes
+func (l *LoxList) Elements() []any {
+	return append([]any(nil), l.elements...)
+}
+
+// checkIndex checks that the Lox value can be used to index the list, and
+// returns it as an int. The error holds the message of the RuntimeError the
+// backends raise at the brackets.
+func (l *LoxList) checkIndex(index any) (int, error) {
+	n, ok := index.(float64)
+	if !ok {
+		return 0, fmt.Errorf("List index must be a number, got %s.", typeName(index))
+	}
+	if n != math.Trunc(n) {
+		return 0, fmt.Errorf("List index must be a whole number, got %v.", stringify(index))
+	}
+	if n < 0 || n >= float64(len(l.elements)) {
+		return 0, fmt.Errorf("Index %v is out of range for a list of length %d.", stringify(index), len(l.elements))
+	}
+	return int(n), nil
+}
+
+func (l *LoxList) get(index any) (any, error) {
+	n, err := l.checkIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	return l.elements[n], nil
+}
+
+func (l *LoxList) set(index any, value any) error {
+	n, err := l.checkIndex(index)
+	if err != nil {
+		return err
+	}
+	l.elements[n] = value
+	return nil
+}
+
+// String prints the elements like print does, except that strings are quoted
+func (l *LoxList) String() string {
+	return l.format(make(map[any]bool))
+}
+
+// format prints the list. seen holds the lists and maps being printed, that
+// the list is inside of, so one that contains itself can be printed as [...].
+func (l *LoxList) format(seen map[any]bool) string {
+	if seen[l] {
+		return "[...]"
+	}
+	seen[l] = true
+	defer delete(seen, l)
+
+	parts := make([]string, 0, len(l.elements))
+	for _, element := range l.elements {
+		parts = append(parts, formatElement(element, seen))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// formatElement prints a value inside a list or map. Strings are quoted,
+// so ["a, b"] can be told apart from ["a", "b"].
+func formatElement(value any, seen map[any]bool) string {
+	switch t := value.(type) {
+	case string:
+		return strconv.Quote(t)
+	case *LoxList:
+		return t.format(seen)
+	case *LoxMap:
+		return t.format(seen)
+	default:
+		return stringify(t)
+	}
+}
+
+// listNatives are the native functions for lists, defined in both backends
+var listNatives = []*nativeFunction{
+	mustNative("len", nativeLen),
+	mustNative("push", nativePush),
+	mustNative("pop", nativePop),
+	mustNative("slice", nativeSlice),
+	mustNative("insert", nativeInsert),
+	mustNative("remove", nativeRemove),
+}
+
+// nativeLen returns the number of elements of a list, keys of a map, or
+// code points of a string
+func nativeLen(value any) (int, error) {
+	switch t := value.(type) {
+	case string:
+		return utf8.RuneCountInString(t), nil
+	case *LoxList:
+		return len(t.elements), nil
+	case *LoxMap:
+		return len(t.keys), nil
+	default:
+		return 0, fmt.Errorf("Can't take the length of a %s.", typeName(value))
+	}
+}
+
+// nativePush adds the values to the end of the list
+func nativePush(list *LoxList, values ...any) {
+	list.elements = append(list.elements, values...)
+}
+
+// nativePop removes the last element of the list, and returns it
+func nativePop(list *LoxList) (any, error) {
+	if len(list.elements) == 0 {
+		return nil, errors.New("Can't pop from an empty list.")
+	}
+	last := list.elements[len(list.elements)-1]
+	list.elements = list.elements[:len(list.elements)-1]
+	return last, nil
+}
+
+// nativeSlice returns a new list with the elements from start up to, but not including, end
+func nativeSlice(list *LoxList, start int, end int) (*LoxList, error) {
+	if start < 0 || end < start || end > len(list.elements) {
+		return nil, fmt.Errorf("Slice from %d to %d is out of range for a list of length %d.", start, end, len(list.elements))
+	}
+	elements := make([]any, end-start)
+	copy(elements, list.elements[start:end])
+	return NewLoxList(elements), nil
+}
+
+// nativeInsert inserts the value before the element at index, or at the end
+// if index is the length of the list
+func nativeInsert(list *LoxList, index int, value any) error {
+	if index < 0 || index > len(list.elements) {
+		return fmt.Errorf("Index %d is out of range for inserting into a list of length %d.", index, len(list.elements))
+	}
+	list.elements = append(list.elements, nil)
+	copy(list.elements[index+1:], list.elements[index:])
+	list.elements[index] = value
+	return nil
+}
+
+// nativeRemove removes the element at index from the list, and returns it
+func nativeRemove(list *LoxList, index int) (any, error) {
+	if index < 0 || index >= len(list.elements) {
+		return nil, fmt.Errorf("Index %d is out of range for a list of length %d.", index, len(list.elements))
+	}
+	removed := list.elements[index]
+	list.elements = append(list.elements[:index], list.elements[index+1:]...)
+	return removed, nil
+}
@@ -0,0 +1,177 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LoxMap is the map type, created with a map literal like {"a": 1, "b": 2}.
+// Keys are strings, numbers, booleans or nil, and are the same key when
+// isEqual says they are equal. Entries are kept in the order they were first
+// added, so printing a map, or looping over keys(m), always gives the same
+// result. Like lists, maps are shared, not copied.
+type LoxMap struct {
+	// keys holds the keys in insertion order
+	keys   []any
+	values map[any]any
+}
+
+// NewLoxMap returns an empty map, see Set for adding entries to it
+func NewLoxMap() *LoxMap {
+	return &LoxMap{values: make(map[any]any)}
+}
+
+// Len returns the number of entries in the map
+func (m *LoxMap) Len() int {
+	return len(m.keys)
+}
+
+// Keys returns the keys of the map, in the order they were first added
+func (m *LoxMap) Keys() []any {
+	return append([]any(nil), m.keys...)
+}
+
+// Get returns the value for the key, and false if the key is not in the map.
+// Go numbers are converted to float64, like the keys scripts use.
+func (m *LoxMap) Get(key any) (any, bool) {
+	loxKey, err := toLox(key)
+	if err != nil || checkKey(loxKey) != nil {
+		return nil, false
+	}
+	value, ok := m.values[loxKey]
+	return value, ok
+}
+
+// Set adds the entry to the map, or replaces the value if the key is already
+// in it. The key and value are converted like in Interpreter.Set.
+func (m *LoxMap) Set(key any, value any) error {
+	loxKey, err := toLox(key)
+	if err != nil {
+		return fmt.Errorf("map key: %w", err)
+	}
+	loxValue, err := toLox(value)
+	if err != nil {
+		return fmt.Errorf("map value: %w", err)
+	}
+	return m.set(loxKey, loxValue)
+}
+
+// checkKey checks that the Lox value can be used as a key. The error holds the
+// message of the RuntimeError the backends raise.
+func checkKey(key any) error {
+	switch t := key.(type) {
+	case nil, bool, string:
+		return nil
+	case float64:
+		// NaN is not equal to itself, so it could be stored but never found
+		if math.IsNaN(t) {
+			return errors.New("NaN can't be used as a map key.")
+		}
+		return nil
+	default:
+		return fmt.Errorf("Map keys must be strings, numbers, booleans or nil, got %s.", typeName(key))
+	}
+}
+
+func (m *LoxMap) get(key any) (any, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	value, ok := m.values[key]
+	if !ok {
+		return nil, fmt.Errorf("Key %s is not in the map.", formatElement(key, nil))
+	}
+	return value, nil
+}
+
+func (m *LoxMap) set(key any, value any) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+	return nil
+}
+
+func (m *LoxMap) has(key any) bool {
+	_, ok := m.values[key]
+	return ok
+}
+
+// delete removes the key, and reports whether it was in the map
+func (m *LoxMap) delete(key any) bool {
+	if !m.has(key) {
+		return false
+	}
+	delete(m.values, key)
+	for n, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:n], m.keys[n+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// String prints the entries in order, with strings quoted like in lists
+func (m *LoxMap) String() string {
+	return m.format(make(map[any]bool))
+}
+
+func (m *LoxMap) format(seen map[any]bool) string {
+	if seen[m] {
+		return "{...}"
+	}
+	seen[m] = true
+	defer delete(seen, m)
+
+	parts := make([]string, 0, len(m.keys))
+	for _, key := range m.keys {
+		parts = append(parts, formatElement(key, seen)+": "+formatElement(m.values[key], seen))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// mapNatives are the native functions for maps, defined in both backends.
+// len works on maps too, see nativeLen.
+var mapNatives = []*nativeFunction{
+	mustNative("keys", nativeKeys),
+	mustNative("values", nativeValues),
+	mustNative("has", nativeHas),
+	mustNative("delete", nativeDelete),
+}
+
+// nativeKeys returns a list of the keys, in the order they were added
+func nativeKeys(m *LoxMap) *LoxList {
+	keys := make([]any, len(m.keys))
+	copy(keys, m.keys)
+	return NewLoxList(keys)
+}
+
+// nativeValues returns a list of the values, in the same order as nativeKeys
+func nativeValues(m *LoxMap) *LoxList {
+	values := make([]any, 0, len(m.keys))
+	for _, key := range m.keys {
+		values = append(values, m.values[key])
+	}
+	return NewLoxList(values)
+}
+
+func nativeHas(m *LoxMap, key any) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+	return m.has(key), nil
+}
+
+// nativeDelete removes the key from the map, and returns whether it was there
+func nativeDelete(m *LoxMap, key any) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+	return m.delete(key), nil
+}
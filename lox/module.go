@@ -0,0 +1,220 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoxModule is the namespace an import statement defines. Scripts read the
+// top-level variables, functions and classes of the module as its properties,
+// like m.fn(). They are read only from outside the module.
+type LoxModule struct {
+	name string
+	// values holds the globals of the module. They are shared with the
+	// module, so its functions see the changes they make to them.
+	values map[string]any
+}
+
+func (m *LoxModule) get(name token) (any, error) {
+	if value, ok := m.values[name.lexeme]; ok {
+		return value, nil
+	}
+	return nil, RuntimeError{
+		token: name,
+		msg:   fmt.Sprintf("Undefined property '%s'.", name.lexeme),
+	}
+}
+
+func (m *LoxModule) String() string {
+	return "<module " + m.name + ">"
+}
+
+// moduleLoader finds, runs and caches the modules a script imports. Both
+// backends have one, running the modules with runModule.
+type moduleLoader struct {
+	// searchPath holds the directories searched for modules, see resolve
+	searchPath []string
+	// disabled is set when scripts can't import modules at all, and root
+	// when they can only import the files in that directory
+	disabled bool
+	root     string
+	// renderer gets the source of every module, and reports their errors
+	// to stderr
+	renderer *renderer
+	stderr   io.Writer
+	// modules holds every module run so far, by absolute path
+	modules map[string]*LoxModule
+	// running holds the files being run, the innermost one last. A module
+	// that imports one of them is part of an import cycle.
+	running []runningFile
+}
+
+type runningFile struct {
+	// name is the file as it is shown in messages, and path its absolute path
+	name string
+	path string
+}
+
+// runModule runs the source of the module, and returns its globals
+type runModule func(file string, source string) (map[string]any, error)
+
+func newModuleLoader(renderer *renderer, stderr io.Writer, searchPath []string) *moduleLoader {
+	return &moduleLoader{
+		searchPath: searchPath,
+		renderer:   renderer,
+		stderr:     stderr,
+		modules:    make(map[string]*LoxModule),
+	}
+}
+
+// run marks the file as running until done is called, so that importing it
+// from a module it imports is reported as a cycle
+func (l *moduleLoader) run(file string) (done func()) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		path = file
+	}
+	l.running = append(l.running, runningFile{name: file, path: path})
+	return func() {
+		l.running = l.running[:len(l.running)-1]
+	}
+}
+
+// load returns the module at path, imported by the import statement with
+// the keyword. A module is only run the first time it is imported, later
+// imports get the same namespace. Runtime errors raised by the module are
+// returned as they are, and StaticErrors once they have been reported.
+func (l *moduleLoader) load(keyword token, path string, run runModule) (*LoxModule, error) {
+	if l.disabled {
+		return nil, RuntimeError{token: keyword, msg: "Importing modules is not allowed."}
+	}
+	file, err := l.resolve(keyword.file, path)
+	if err != nil {
+		return nil, RuntimeError{token: keyword, msg: err.Error()}
+	}
+	absolute, err := filepath.Abs(file)
+	if err != nil {
+		return nil, RuntimeError{token: keyword, msg: err.Error()}
+	}
+
+	if module, ok := l.modules[absolute]; ok {
+		return module, nil
+	}
+	for n, running := range l.running {
+		if running.path == absolute {
+			return nil, RuntimeError{token: keyword, msg: l.cycle(n, file)}
+		}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, RuntimeError{token: keyword, msg: fmt.Sprintf("Can't read module '%s'.", path)}
+	}
+	source := string(data)
+	l.renderer.addSource(file, source)
+
+	done := l.run(file)
+	values, err := run(file, source)
+	done()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	module := &LoxModule{name: name, values: values}
+	l.modules[absolute] = module
+	return module, nil
+}
+
+// resolve returns the file the import of path in the importer refers to.
+// Paths starting with ./ or ../ are relative to the directory of the
+// importer, other relative paths are looked up there first, and then in
+// every directory of the search path. With a root, absolute paths and
+// files outside of the root are refused.
+func (l *moduleLoader) resolve(importer string, path string) (string, error) {
+	if filepath.IsAbs(path) {
+		if l.root != "" {
+			return "", fmt.Errorf("Can't import '%s', absolute paths are not allowed.", path)
+		}
+		return path, nil
+	}
+
+	// Code that is not in a file, like Eval, imports from the working
+	// directory, or the root if there is one
+	dir := "."
+	if !strings.HasPrefix(importer, "<") {
+		dir = filepath.Dir(importer)
+	} else if l.root != "" {
+		dir = l.root
+	}
+	candidates := []string{filepath.Join(dir, path)}
+	if !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") {
+		for _, searchDir := range l.searchPath {
+			candidates = append(candidates, filepath.Join(searchDir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		// Checked first, so scripts can't find out which files outside of it exist
+		if !l.inRoot(candidate) {
+			return "", fmt.Errorf("Can't import '%s', it is outside of the module root.", path)
+		}
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("Can't read module '%s'.", path)
+		}
+	}
+	return "", fmt.Errorf("Can't find module '%s'.", path)
+}
+
+// inRoot reports whether the file is in the root directory, or whether there
+// is no root. Symbolic links are followed if the file exists.
+func (l *moduleLoader) inRoot(file string) bool {
+	if l.root == "" {
+		return true
+	}
+	if !isWithin(l.root, file) {
+		return false
+	}
+	root, err := filepath.EvalSymlinks(l.root)
+	if err != nil {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	return err == nil && isWithin(root, resolved)
+}
+
+// isWithin reports whether the path is the directory or below it
+func isWithin(dir string, path string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// cycle describes the import cycle from the n-th running file back to file
+func (l *moduleLoader) cycle(n int, file string) string {
+	var names []string
+	for _, running := range l.running[n:] {
+		names = append(names, running.name)
+	}
+	names = append(names, file)
+	return "Import cycle: " + strings.Join(names, " -> ") + "."
+}
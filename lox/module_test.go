@@ -0,0 +1,110 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files, by path relative to dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// runtimeMessage returns the message of the RuntimeError in err
+func runtimeMessage(t *testing.T, err error) string {
+	t.Helper()
+	var runtimeErr RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected a RuntimeError, got %v", err)
+	}
+	return runtimeErr.Message()
+}
+
+func TestImportRegisteredFunction(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"mod.lox": "fun call() { return hostfn(2); }",
+	})
+
+	var out bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(io.Discard), WithModuleRoot(dir))
+	if err := interpreter.RegisterFunction("hostfn", func(n float64) float64 { return n * 10 }); err != nil {
+		t.Fatal(err)
+	}
+	if err := interpreter.Eval(`import "mod.lox" as m; print m.call();`); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "20\n" {
+		t.Errorf("got %q, want %q", got, "20\n")
+	}
+}
+
+func TestWithImportsDisabled(t *testing.T) {
+	interpreter := New(WithStderr(io.Discard), WithImports(false))
+	err := interpreter.Eval(`import "anything.lox" as m;`)
+	if msg := runtimeMessage(t, err); msg != "Importing modules is not allowed." {
+		t.Errorf("got %q", msg)
+	}
+}
+
+func TestWithModuleRoot(t *testing.T) {
+	outside := t.TempDir()
+	root := t.TempDir()
+	writeFiles(t, outside, map[string]string{"secret.lox": "var secret = 1;"})
+	writeFiles(t, root, map[string]string{
+		"lib/inside.lox": `import "../top.lox" as top; var name = top.name;`,
+		"top.lox":        `var name = "top";`,
+	})
+	if err := os.Symlink(filepath.Join(outside, "secret.lox"), filepath.Join(root, "link.lox")); err != nil {
+		t.Fatal(err)
+	}
+	relative, err := filepath.Rel(root, filepath.Join(outside, "secret.lox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(outside, "secret.lox"), "absolute paths are not allowed"},
+		{relative, "outside of the module root"},
+		{"link.lox", "outside of the module root"},
+		{"../missing.lox", "outside of the module root"},
+		{"missing.lox", "Can't find module"},
+	}
+	for _, test := range tests {
+		var stderr bytes.Buffer
+		interpreter := New(WithStderr(&stderr), WithModuleRoot(root))
+		err := interpreter.Eval(`import "` + test.path + `" as m;`)
+		if msg := runtimeMessage(t, err); !strings.Contains(msg, test.want) {
+			t.Errorf("importing %s: got %q, want it to contain %q", test.path, msg, test.want)
+		}
+		if strings.Contains(stderr.String(), "var secret") {
+			t.Errorf("importing %s printed the file outside of the root: %s", test.path, stderr.String())
+		}
+	}
+
+	var out bytes.Buffer
+	interpreter := New(WithStdout(&out), WithStderr(io.Discard), WithModuleRoot(root))
+	if err := interpreter.Eval(`import "lib/inside.lox" as m; print m.name;`); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "top\n" {
+		t.Errorf("got %q, want %q", got, "top\n")
+	}
+}
@@ -0,0 +1,24 @@
+package lox
+
+import (
+	"fmt"
+	"time"
+)
+
+type clock struct{}
+
+// Type check, just to be safe
+var _ LoxCallable = &clock{}
+
+func (c *clock) Arity() int {
+	return 0
+}
+
+func (c *clock) Call(interpreter *Interpreter, arguments []any) (any, error) {
+	// Lox only has one number type, so seconds are returned as a float64
+	return float64(time.Now().UnixNano()) / float64(time.Second), nil
+}
+
+func (c *clock) String() string {
+	return fmt.Sprintf("<native fn Clock()>")
+}
@@ -0,0 +1,303 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// nativeFunction is a Go function that can be called from Lox. The Lox
+// arguments are converted to the types of the Go parameters, and the result
+// back to a Lox value, so any Go function can be used without writing a
+// LoxCallable for it like clock.
+type nativeFunction struct {
+	name string
+	fn   reflect.Value
+	// params holds the type of every parameter. For variadic functions the
+	// last one is the type of the variadic arguments, not the slice of them.
+	params   []reflect.Type
+	variadic bool
+	// returnsError is set when the last result of the function is an error
+	returnsError bool
+}
+
+// Type check, just to be safe
+var _ LoxCallable = &nativeFunction{}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// newNativeFunction wraps the Go function, which can return nothing, a value,
+// an error, or a value and an error. A non-nil error is raised as a runtime
+// error at the call, and name is used for the function in error messages.
+func newNativeFunction(name string, fn any) (*nativeFunction, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%s is a %T, not a function", name, fn)
+	}
+
+	t := v.Type()
+	native := &nativeFunction{
+		name:     name,
+		fn:       v,
+		variadic: t.IsVariadic(),
+	}
+	for n := 0; n < t.NumIn(); n++ {
+		param := t.In(n)
+		if native.variadic && n == t.NumIn()-1 {
+			param = param.Elem()
+		}
+		native.params = append(native.params, param)
+	}
+
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		native.returnsError = t.Out(0) == errorType
+	case 2:
+		if t.Out(1) != errorType {
+			return nil, fmt.Errorf("the second result of %s must be an error, not %v", name, t.Out(1))
+		}
+		native.returnsError = true
+	default:
+		return nil, fmt.Errorf("%s has %d results, it can only return a value, an error or both", name, t.NumOut())
+	}
+	return native, nil
+}
+
+// mustNative wraps one of our own native functions, which are known to be valid
+func mustNative(name string, fn any) *nativeFunction {
+	native, err := newNativeFunction(name, fn)
+	if err != nil {
+		panic(err)
+	}
+	return native
+}
+
+// RegisterFunction defines the Go function as a global Lox function. The Lox
+// arguments are converted to the types of its parameters, and the result
+// back to a Lox value. It can return nothing, a value, an error, or a value
+// and an error. A non-nil error is raised as a runtime error at the call.
+// Modules imported by scripts can call it too.
+func (i *Interpreter) RegisterFunction(name string, fn any) error {
+	native, err := newNativeFunction(name, fn)
+	if err != nil {
+		return fmt.Errorf("registering %s: %w", name, err)
+	}
+	i.defineNatives([]*nativeFunction{native})
+	return nil
+}
+
+// Arity is the number of parameters, not counting the variadic one
+func (n *nativeFunction) Arity() int {
+	if n.variadic {
+		return len(n.params) - 1
+	}
+	return len(n.params)
+}
+
+func (n *nativeFunction) isVariadic() bool {
+	return n.variadic
+}
+
+func (n *nativeFunction) Call(interpreter *Interpreter, arguments []any) (any, error) {
+	in := make([]reflect.Value, 0, len(arguments))
+	for j, argument := range arguments {
+		param := n.params[clamp(j, 0, len(n.params)-1)]
+		value, err := fromLox(argument, param)
+		if err != nil {
+			return nil, fmt.Errorf("Argument %d to '%s' %v.", j+1, n.name, err)
+		}
+		in = append(in, value)
+	}
+
+	out, err := n.call(in)
+	if err != nil {
+		return nil, err
+	}
+	if n.returnsError {
+		if err := out[len(out)-1]; !err.IsNil() {
+			return nil, err.Interface().(error)
+		}
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	result, err := toLox(out[0].Interface())
+	if err != nil {
+		return nil, fmt.Errorf("%s returned a value Lox can't use: %w", n.name, err)
+	}
+	return result, nil
+}
+
+// call calls the Go function. A panic in it is returned as an error, which
+// stops the script at the call instead of crashing the host.
+func (n *nativeFunction) call(in []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panicked: %v", n.name, r)
+		}
+	}()
+	return n.fn.Call(in), nil
+}
+
+func (n *nativeFunction) String() string {
+	return fmt.Sprintf("<native fn %s>", n.name)
+}
+
+// fromLox converts the Lox value to the Go type. Numbers can be passed to
+// any Go number type, but only whole numbers that fit to integer types.
+// The error says what went wrong, to be put after "Argument 1 to 'f'".
+func fromLox(value any, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("must be %s, got nil", goTypeName(t))
+	}
+
+	mismatch := fmt.Errorf("must be %s, got %s", goTypeName(t), typeName(value))
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			return reflect.ValueOf(b).Convert(t), nil
+		}
+		return reflect.Value{}, mismatch
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+		return reflect.Value{}, mismatch
+	case reflect.Float32, reflect.Float64:
+		if f, ok := value.(float64); ok {
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+		return reflect.Value{}, mismatch
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, ok := value.(float64)
+		if !ok {
+			return reflect.Value{}, mismatch
+		} else if f != math.Trunc(f) {
+			return reflect.Value{}, fmt.Errorf("must be %s, got %v", goTypeName(t), stringify(value))
+		}
+		v := reflect.New(t).Elem()
+		if f < math.MinInt64 || f >= math.MaxInt64 || v.OverflowInt(int64(f)) {
+			return reflect.Value{}, fmt.Errorf("is out of range for %v, got %v", t, stringify(value))
+		}
+		v.SetInt(int64(f))
+		return v, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		f, ok := value.(float64)
+		if !ok {
+			return reflect.Value{}, mismatch
+		} else if f != math.Trunc(f) || f < 0 {
+			return reflect.Value{}, fmt.Errorf("must be %s, got %v", goTypeName(t), stringify(value))
+		}
+		v := reflect.New(t).Elem()
+		if f >= math.MaxUint64 || v.OverflowUint(uint64(f)) {
+			return reflect.Value{}, fmt.Errorf("is out of range for %v, got %v", t, stringify(value))
+		}
+		v.SetUint(uint64(f))
+		return v, nil
+	}
+
+	// Lists are copied into a new slice, converting every element
+	if list, ok := value.(*LoxList); ok && t.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(t, 0, len(list.elements))
+		for n, element := range list.elements {
+			converted, err := fromLox(element, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("is a list whose element %d %v", n, err)
+			}
+			slice = reflect.Append(slice, converted)
+		}
+		return slice, nil
+	}
+
+	// Maps are copied into a new Go map, converting every key and value
+	if m, ok := value.(*LoxMap); ok && t.Kind() == reflect.Map {
+		goMap := reflect.MakeMapWithSize(t, len(m.keys))
+		for _, key := range m.keys {
+			goKey, err := fromLox(key, t.Key())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("is a map whose key %s %v", formatElement(key, nil), err)
+			}
+			goValue, err := fromLox(m.values[key], t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("is a map whose value for %s %v", formatElement(key, nil), err)
+			}
+			goMap.SetMapIndex(goKey, goValue)
+		}
+		return goMap, nil
+	}
+
+	// GoObjects are passed as the struct or map they wrap
+	if object, ok := value.(*GoObject); ok && object.value.Type().AssignableTo(t) {
+		return object.value, nil
+	}
+
+	// Anything else, like an any parameter or a LoxCallable, gets the Lox value itself
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	return reflect.Value{}, mismatch
+}
+
+// goTypeName describes the Go type in terms of the Lox values it can hold
+func goTypeName(t reflect.Type) string {
+	if t == reflect.TypeOf(&LoxList{}) {
+		return "a list"
+	}
+	if t == reflect.TypeOf(&LoxMap{}) {
+		return "a map"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "a whole number"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "a non-negative whole number"
+	default:
+		return fmt.Sprintf("a %v", t)
+	}
+}
+
+// typeName returns the name of the type of the Lox value, for error messages
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *LoxClass, *objClass:
+		return "class"
+	case *LoxInstance, *objInstance:
+		return "instance"
+	case *GoObject:
+		return "object"
+	case *LoxList:
+		return "list"
+	case *LoxMap:
+		return "map"
+	case *LoxError:
+		return "error"
+	case *LoxModule:
+		return "module"
+	case LoxCallable, *objClosure, *objBoundMethod:
+		return "function"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
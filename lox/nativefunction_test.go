@@ -0,0 +1,92 @@
+package lox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newWithNatives returns an interpreter with the functions registered
+func newWithNatives(t *testing.T, out io.Writer, natives map[string]any) *Interpreter {
+	t.Helper()
+	interpreter := New(WithStdout(out), WithStderr(io.Discard))
+	for name, fn := range natives {
+		if err := interpreter.RegisterFunction(name, fn); err != nil {
+			t.Fatalf("registering %s: %v", name, err)
+		}
+	}
+	return interpreter
+}
+
+func TestRegisterFunction(t *testing.T) {
+	var out bytes.Buffer
+	interpreter := newWithNatives(t, &out, map[string]any{
+		"greet": func(name string) string { return "hello " + name },
+		"sum": func(numbers ...int) int {
+			total := 0
+			for _, n := range numbers {
+				total += n
+			}
+			return total
+		},
+		"join":  func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"nop":   func() {},
+		"pair":  func() []any { return []any{1, "a"} },
+		"count": func(m map[string]int) int { return len(m) },
+	})
+	err := interpreter.Eval(`
+print greet("lox");
+print sum();
+print sum(1, 2, 3);
+print join("-", "a", "b");
+print nop() == nil;
+print pair();
+print count({"a": 1, "b": 2});`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "hello lox\n0\n6\na-b\ntrue\n[1, \"a\"]\n2\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := interpreter.RegisterFunction("notAFunction", 1); err == nil {
+		t.Error("RegisterFunction accepted an int")
+	}
+}
+
+func TestRegisteredFunctionErrors(t *testing.T) {
+	interpreter := newWithNatives(t, io.Discard, map[string]any{
+		"half": func(n int) int { return n / 2 },
+		"byte": func(b uint8) uint8 { return b },
+		"join": func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"fail": func() error { return errors.New("Something went wrong.") },
+		"ch":   func() chan int { return make(chan int) },
+		"boom": func() int { panic("boom") },
+		"nth":  func(xs []int, n int) int { return xs[n] },
+	})
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`half();`, "Expected 1 arguments but got 0."},
+		{`half(1, 2);`, "Expected 1 arguments but got 2."},
+		{`join();`, "Expected at least 1 arguments but got 0."},
+		{`half("a");`, "Argument 1 to 'half' must be a whole number, got string."},
+		{`half(1.5);`, "Argument 1 to 'half' must be a whole number, got 1.5."},
+		{`byte(256);`, "Argument 1 to 'byte' is out of range for uint8, got 256."},
+		{`join("-", "a", 1);`, "Argument 3 to 'join' must be a string, got number."},
+		{`fail();`, "Something went wrong."},
+		{`ch();`, "ch returned a value Lox can't use: can't use chan int as a Lox value"},
+		{`boom();`, "boom panicked: boom"},
+		{`nth([1], 1);`, "nth panicked: runtime error: index out of range [1] with length 1"},
+	}
+	for _, test := range tests {
+		err := interpreter.Eval(test.source)
+		if msg := runtimeMessage(t, err); msg != test.want {
+			t.Errorf("%s: got %q, want %q", test.source, msg, test.want)
+		}
+	}
+}
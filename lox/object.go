@@ -0,0 +1,104 @@
+package lox
+
+// Heap objects used by the bytecode VM. Numbers, strings, booleans and nil
+// are represented the same way as in the tree-walking interpreter, so
+// stringify and isEqual work for both backends.
+// https://craftinginterpreters.com/strings.html
+
+// objFunction is a compiled function prototype. It is created by the
+// compiler and never changes at runtime.
+// https://craftinginterpreters.com/calls-and-functions.html#function-objects
+type objFunction struct {
+	name         string
+	arity        int
+	upvalueCount int
+	chunk        *chunk
+}
+
+func newObjFunction() *objFunction {
+	return &objFunction{
+		chunk: &chunk{},
+	}
+}
+
+func (f *objFunction) String() string {
+	if f.name == "" {
+		return "<script>"
+	}
+	return "<fn " + f.name + ">"
+}
+
+// objUpvalue is a variable captured by a closure. While the variable is
+// still on the stack the upvalue refers to its stack slot, once it goes
+// out of scope the value is moved into closed.
+// https://craftinginterpreters.com/closures.html#upvalues
+type objUpvalue struct {
+	slot   int
+	open   bool
+	closed any
+	// next open upvalue further down the stack
+	next *objUpvalue
+}
+
+// https://craftinginterpreters.com/closures.html#closure-objects
+type objClosure struct {
+	function *objFunction
+	upvalues []*objUpvalue
+	// globals holds the globals of the script or module the closure was
+	// created in, which it sees wherever it is called from
+	globals map[string]any
+}
+
+func newObjClosure(function *objFunction) *objClosure {
+	return &objClosure{
+		function: function,
+		upvalues: make([]*objUpvalue, function.upvalueCount),
+	}
+}
+
+func (c *objClosure) String() string {
+	return c.function.String()
+}
+
+// https://craftinginterpreters.com/classes-and-instances.html#class-objects
+type objClass struct {
+	name    string
+	methods map[string]*objClosure
+}
+
+func newObjClass(name string) *objClass {
+	return &objClass{
+		name:    name,
+		methods: make(map[string]*objClosure),
+	}
+}
+
+func (c *objClass) String() string {
+	return c.name
+}
+
+type objInstance struct {
+	class  *objClass
+	fields map[string]any
+}
+
+func newObjInstance(class *objClass) *objInstance {
+	return &objInstance{
+		class:  class,
+		fields: make(map[string]any),
+	}
+}
+
+func (i *objInstance) String() string {
+	return i.class.name + " instance"
+}
+
+// https://craftinginterpreters.com/methods-and-initializers.html#bound-methods
+type objBoundMethod struct {
+	receiver any
+	method   *objClosure
+}
+
+func (b *objBoundMethod) String() string {
+	return b.method.String()
+}
@@ -0,0 +1,1138 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+type parser struct {
+	tokens      []token
+	current     int
+	diagnostics []Diagnostic
+}
+
+func newParser(tokens []token) parser {
+	return parser{
+		tokens: tokens,
+	}
+}
+
+// parse parses the whole program. It keeps going after syntax errors, so
+// every error in the program is returned, not just the first. The statements
+// can't be run if any errors are returned, but they never contain nil.
+func (p *parser) parse() ([]statement, []Diagnostic) {
+	statements := []statement{}
+	for !p.isAtEnd() {
+		if stmt := p.declaration(); stmt != nil {
+			statements = append(
+				statements,
+				stmt,
+			)
+		}
+	}
+	return statements, p.diagnostics
+}
+
+func (p *parser) expression() (expression, error) {
+	expr, err := p.assignment()
+	if err != nil {
+		return nil, fmt.Errorf("assignment(): %w", err)
+	}
+	return expr, nil
+}
+
+// declaration returns nil if there was a syntax error, after skipping ahead to the next statement
+// https://craftinginterpreters.com/parsing-expressions.html#synchronizing-a-recursive-descent-parser
+func (p *parser) declaration() statement {
+	// try
+	var err error
+	var res statement
+	if p.match(tokenClass) {
+		res, err = p.classDeclaration()
+	} else if p.match(tokenFun) {
+		res, err = p.function("function", p.previous())
+	} else if p.match(tokenVar) {
+		res, err = p.varDeclaration()
+	} else if p.match(tokenImport) {
+		res, err = p.importDeclaration()
+	} else {
+		res, err = p.statement()
+	}
+	if err != nil {
+		// The error is already in p.diagnostics
+		p.synchronize()
+		return nil
+	}
+	return res
+}
+
+// https://craftinginterpreters.com/classes.html#class-declarations
+func (p *parser) classDeclaration() (statement, error) {
+	keyword := p.previous()
+	name, err := p.consume(tokenIdentifier, "Expect class name.")
+	if err != nil {
+		return nil, fmt.Errorf("consuming identifier: %w", err)
+	}
+
+	// https://craftinginterpreters.com/inheritance.html#superclasses-and-subclasses
+	var superclass *variableExpr = nil
+	if p.match(tokenLess) {
+		if _, err := p.consume(tokenIdentifier, "Expect superclass name."); err != nil {
+			return nil, fmt.Errorf("consuming superclass name: %w", err)
+		}
+		superclass = &variableExpr{
+			Span: p.previous().span(),
+			name: p.previous(),
+		}
+	}
+
+	if _, err := p.consume(tokenLeftBrace, "Expect '{' before class body."); err != nil {
+		return nil, fmt.Errorf("consuming LEFT_BRACE: %w", err)
+	}
+
+	var methods []functionStmt
+	for !p.check(tokenRightBrace) && !p.isAtEnd() {
+		method, err := p.function("method", p.peek())
+		if err != nil {
+			return nil, fmt.Errorf("parsing method: %w", err)
+		}
+		methods = append(
+			methods,
+			method,
+		)
+	}
+
+	if _, err := p.consume(tokenRightBrace, "Expect '}' after class body."); err != nil {
+		return nil, fmt.Errorf("consuming RIGHT_BRACE: %w", err)
+	}
+
+	return classStmt{
+		Span:       p.spanFrom(keyword),
+		name:       name,
+		superclass: superclass,
+		methods:    methods,
+	}, nil
+}
+
+func (p *parser) varDeclaration() (statement, error) {
+	keyword := p.previous()
+	name, err := p.consume(tokenIdentifier, "Expect variable name.")
+	if err != nil {
+		return nil, fmt.Errorf("consuming identifier: %w", err)
+	}
+	var initializer expression = nil
+	if p.match(tokenEqual) {
+		initializer, err = p.expression()
+		if err != nil {
+			return nil, fmt.Errorf("expression(): %w", err)
+		}
+	}
+
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after variable declaration."); err != nil {
+		return nil, fmt.Errorf("consuming semicolon: %w", err)
+	}
+	return varStmt{
+		Span:        p.spanFrom(keyword),
+		name:        name,
+		initializer: initializer,
+	}, nil
+}
+
+func (p *parser) importDeclaration() (statement, error) {
+	keyword := p.previous()
+	path, err := p.consume(tokenString, "Expect module path after 'import'.")
+	if err != nil {
+		return nil, fmt.Errorf("consuming module path: %w", err)
+	}
+	if _, err := p.consume(tokenAs, "Expect 'as' after module path."); err != nil {
+		return nil, fmt.Errorf("consuming as: %w", err)
+	}
+	name, err := p.consume(tokenIdentifier, "Expect module name after 'as'.")
+	if err != nil {
+		return nil, fmt.Errorf("consuming identifier: %w", err)
+	}
+
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after import."); err != nil {
+		return nil, fmt.Errorf("consuming semicolon: %w", err)
+	}
+	return importStmt{
+		Span:    p.spanFrom(keyword),
+		keyword: keyword,
+		path:    path,
+		name:    name,
+	}, nil
+}
+
+func (p *parser) whileStatement() (statement, error) {
+	keyword := p.previous()
+	if _, err := p.consume(tokenLeftParen, "Expect '(' after 'while'."); err != nil {
+		return nil, fmt.Errorf("consuming LEFT_PAREN: %w", err)
+	}
+	condition, err := p.expression()
+	if err != nil {
+		return nil, fmt.Errorf("expression(): %w", err)
+	}
+	if _, err := p.consume(tokenRightParen, "Expect ')' after condition."); err != nil {
+		return nil, fmt.Errorf("consuming RIGHT_PAREN: %w", err)
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, fmt.Errorf("getting statement for body: %w", err)
+	}
+	return whileStmt{
+		Span:      p.spanFrom(keyword),
+		condition: condition,
+		body:      body,
+	}, nil
+}
+
+func (p *parser) statement() (statement, error) {
+	if p.match(tokenBreak) {
+		return p.breakStatement()
+	}
+	if p.match(tokenContinue) {
+		return p.continueStatement()
+	}
+	if p.match(tokenFor) {
+		return p.forStatement()
+	}
+	if p.match(tokenIf) {
+		return p.ifStatement()
+	}
+	if p.match(tokenPrint) {
+		return p.printStatement()
+	}
+	if p.match(tokenReturn) {
+		return p.returnStatement()
+	}
+	if p.match(tokenThrow) {
+		return p.throwStatement()
+	}
+	if p.match(tokenTry) {
+		return p.tryStatement()
+	}
+	if p.match(tokenWhile) {
+		return p.whileStatement()
+	}
+
+	// https://craftinginterpreters.com/statements-and-state.html#block-syntax-and-semantics
+	if p.match(tokenLeftBrace) {
+		brace := p.previous()
+		statements, err := p.block()
+		if err != nil {
+			return nil, fmt.Errorf("parsing block: %w", err)
+		}
+		return blockStmt{
+			Span:       p.spanFrom(brace),
+			statements: statements,
+		}, nil
+	}
+
+	return p.expressionStatement()
+}
+
+func (p *parser) forStatement() (statement, error) {
+	var zero statement = nil
+	keyword := p.previous()
+	if _, err := p.consume(tokenLeftParen, "Expect '(' after 'for'."); err != nil {
+		return nil, fmt.Errorf("consuming LEFT_PAREN: %w", err)
+	}
+
+	var initializer statement
+	var initializerIsSet bool
+
+	if p.match(tokenSemicolon) {
+		initializerIsSet = false
+		// initializer = nil
+	} else if p.match(tokenVar) {
+		tmp, err := p.varDeclaration()
+		if err != nil {
+			return zero, fmt.Errorf("handling initializer var declaration: %w", err)
+		}
+		initializer = tmp
+		initializerIsSet = true
+	} else {
+		tmp, err := p.expressionStatement()
+		if err != nil {
+			return zero, fmt.Errorf("handling initializer expr stmt: %w", err)
+		}
+		initializer = tmp
+		initializerIsSet = true
+	}
+
+	var condition expression
+	var conditionIsSet bool
+	if !p.check(tokenSemicolon) {
+		tmp, err := p.expression()
+		if err != nil {
+			return nil, fmt.Errorf("expression(): %w", err)
+		}
+		condition = tmp
+
+		conditionIsSet = true
+	}
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after loop condition."); err != nil {
+		return nil, fmt.Errorf("consuming SEMICOLON: %w", err)
+	}
+
+	var increment expression
+	if !p.check(tokenRightParen) {
+		tmp, err := p.expression()
+		if err != nil {
+			return nil, fmt.Errorf("expression(): %w", err)
+		}
+		increment = tmp
+	}
+	if _, err := p.consume(tokenRightParen, "Expect ')' after for clauses."); err != nil {
+		return nil, fmt.Errorf("consuming RIGHT_PAREN: %w", err)
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return zero, fmt.Errorf("getting statement in for loop desugaring: %w", err)
+	}
+
+	// The desugared statements all get the span of the whole for loop
+	span := p.spanFrom(keyword)
+
+	// Note reverse check
+	if !conditionIsSet {
+		condition = &literalExpr{
+			Span:  span,
+			value: true,
+		}
+	}
+
+	// The increment is kept on the loop rather than appended to the body in
+	// a block, so that a continue in the body still runs it
+	body = whileStmt{
+		Span:      span,
+		condition: condition,
+		body:      body,
+		increment: increment,
+	}
+
+	if initializerIsSet {
+		body = blockStmt{
+			Span:       span,
+			statements: []statement{initializer, body},
+		}
+	}
+
+	return body, nil
+
+}
+
+func (p *parser) ifStatement() (statement, error) {
+	keyword := p.previous()
+	if _, err := p.consume(tokenLeftParen, "Expect '(' after if condition."); err != nil {
+		return nil, fmt.Errorf("consuming LEFT_PAREN: %w", err)
+	}
+	condition, err := p.expression()
+	if err != nil {
+		return nil, fmt.Errorf("expression(): %w", err)
+	}
+	if _, err := p.consume(tokenRightParen, "Expect ')' after if condition."); err != nil {
+		return nil, fmt.Errorf("consuming RIGHT_PAREN: %w", err)
+	}
+
+	thenBranch, err := p.statement()
+	if err != nil {
+		return nil, fmt.Errorf("getting statement for then branch: %w", err)
+	}
+	// TODO: Careful! Using nil interface here.. does it work?
+	var elseBranch statement = nil
+	if p.match(tokenElse) {
+		tmp, err := p.statement()
+		if err != nil {
+			return nil, fmt.Errorf("getting statement for else branch: %w", err)
+		}
+		elseBranch = tmp
+	}
+
+	return ifStmt{
+		Span:       p.spanFrom(keyword),
+		condition:  condition,
+		thenBranch: thenBranch,
+		elseBranch: elseBranch,
+	}, nil
+}
+
+func (p *parser) printStatement() (statement, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, fmt.Errorf("expression(): %w", err)
+	}
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after value."); err != nil {
+		return nil, fmt.Errorf("consuming semicolon: %w", err)
+	}
+	return printStmt{
+		Span:       p.spanFrom(keyword),
+		expression: value,
+	}, nil
+}
+
+func (p *parser) throwStatement() (statement, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after thrown value."); err != nil {
+		return nil, err
+	}
+	return throwStmt{
+		Span:    p.spanFrom(keyword),
+		keyword: keyword,
+		value:   value,
+	}, nil
+}
+
+func (p *parser) tryStatement() (statement, error) {
+	keyword := p.previous()
+	body, err := p.blockStatement("Expect '{' after 'try'.")
+	if err != nil {
+		return nil, err
+	}
+
+	var catch *catchClause
+	if p.match(tokenCatch) {
+		if _, err := p.consume(tokenLeftParen, "Expect '(' after 'catch'."); err != nil {
+			return nil, err
+		}
+		name, err := p.consume(tokenIdentifier, "Expect error variable name.")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consume(tokenRightParen, "Expect ')' after error variable name."); err != nil {
+			return nil, err
+		}
+		catchBody, err := p.blockStatement("Expect '{' before catch body.")
+		if err != nil {
+			return nil, err
+		}
+		catch = &catchClause{name: name, body: catchBody.statements}
+	}
+
+	var finally *blockStmt
+	if p.match(tokenFinally) {
+		finallyBody, err := p.blockStatement("Expect '{' after 'finally'.")
+		if err != nil {
+			return nil, err
+		}
+		finally = &finallyBody
+	}
+
+	if catch == nil && finally == nil {
+		// Reported without synchronizing, the next statement can be parsed as usual
+		p.error(p.previous(), CODE_SYNTAX, "Expect 'catch' or 'finally' after try block.")
+	}
+	return tryStmt{
+		Span:    p.spanFrom(keyword),
+		keyword: keyword,
+		body:    body,
+		catch:   catch,
+		finally: finally,
+	}, nil
+}
+
+// interpolation parses an interpolated string, after its first tokenInterpolation.
+// The scanner gives "a ${b} c ${d} e" as the tokens INTERPOLATION("a "), b,
+// INTERPOLATION(" c "), d, STRING(" e").
+func (p *parser) interpolation() (expression, error) {
+	start := p.previous()
+	var parts []expression
+	text := func(segment token) {
+		// Leave out the empty text, like the one before a ${ at the start
+		if value := segment.literal.(string); value != "" {
+			parts = append(parts, &literalExpr{Span: segment.span(), value: value})
+		}
+	}
+
+	text(start)
+	for {
+		// The rest of the string starts with the } that ends the expression,
+		// a string in the expression starts with a quote
+		if next := p.peek(); (next.tokenType == tokenString || next.tokenType == tokenInterpolation) && strings.HasPrefix(next.lexeme, "}") {
+			next.lexeme = "}"
+			return nil, p.error(next, CODE_SYNTAX, "Expect expression in string interpolation.")
+		}
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+
+		if p.match(tokenInterpolation) {
+			text(p.previous())
+			continue
+		}
+		end, err := p.consume(tokenString, "Expect '}' after expression in string interpolation.")
+		if err != nil {
+			return nil, err
+		}
+		text(end)
+		return &interpolationExpr{
+			Span:  p.spanFrom(start),
+			parts: parts,
+		}, nil
+	}
+}
+
+// blockStatement parses a block that must be there, like the body of a try
+func (p *parser) blockStatement(message string) (blockStmt, error) {
+	brace, err := p.consume(tokenLeftBrace, message)
+	if err != nil {
+		return blockStmt{}, err
+	}
+	statements, err := p.block()
+	if err != nil {
+		return blockStmt{}, err
+	}
+	return blockStmt{
+		Span:       p.spanFrom(brace),
+		statements: statements,
+	}, nil
+}
+
+// breakStatement parses a break. Whether it is inside a loop is checked by the resolver.
+func (p *parser) breakStatement() (statement, error) {
+	keyword := p.previous()
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after 'break'."); err != nil {
+		return nil, err
+	}
+	return breakStmt{
+		Span:    p.spanFrom(keyword),
+		keyword: keyword,
+	}, nil
+}
+
+func (p *parser) continueStatement() (statement, error) {
+	keyword := p.previous()
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after 'continue'."); err != nil {
+		return nil, err
+	}
+	return continueStmt{
+		Span:    p.spanFrom(keyword),
+		keyword: keyword,
+	}, nil
+}
+
+func (p *parser) returnStatement() (statement, error) {
+	keyword := p.previous()
+	var value expression = nil
+	if !p.check(tokenSemicolon) {
+		tmp, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		value = tmp
+	}
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after return value."); err != nil {
+		return nil, err
+	}
+	return returnStmt{
+		Span:    p.spanFrom(keyword),
+		keyword: keyword,
+		value:   value,
+	}, nil
+}
+
+func (p *parser) expressionStatement() (statement, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, fmt.Errorf("expression(): %w", err)
+	}
+	if _, err := p.consume(tokenSemicolon, "Expect ';' after expression."); err != nil {
+		return nil, fmt.Errorf("soncuming semicolon: %w", err)
+	}
+	return expressionStmt{
+		Span:       joinSpans(expr.span(), p.previous().span()),
+		expression: expr,
+	}, nil
+}
+
+// function parses a function or method. start is the first token of the
+// declaration, the "fun" keyword for functions and the name for methods.
+func (p *parser) function(kind string, start token) (functionStmt, error) {
+	var zero functionStmt
+
+	name, err := p.consume(tokenIdentifier, "Expect "+kind+" name.")
+	if err != nil {
+		return zero, fmt.Errorf("consuming identifier: %w", err)
+	}
+
+	if _, err := p.consume(tokenLeftParen, "Expect '(' after "+kind+" name."); err != nil {
+		return zero, fmt.Errorf("consuming identifier: %w", err)
+	}
+
+	var parameters []token
+	if !p.check(tokenRightParen) {
+		for true {
+			if len(parameters) >= 255 {
+				p.error(p.peek(), CODE_TOO_MANY_ARGUMENTS, "Can't have more than 255 parameters.")
+			}
+
+			tmp, err := p.consume(tokenIdentifier, "Expect parameter name.")
+			if err != nil {
+				return zero, fmt.Errorf("consuming identifier: %w", err)
+			}
+			parameters = append(
+				parameters,
+				tmp,
+			)
+
+			if !p.match(tokenComma) {
+				break
+			}
+		}
+	}
+	if _, err := p.consume(tokenRightParen, "Expect ')' after parameters."); err != nil {
+		return zero, fmt.Errorf("consuming RIGHT_PAREN: %w", err)
+	}
+
+	if _, err := p.consume(tokenLeftBrace, "Expect '{' before "+kind+" body."); err != nil {
+		return zero, fmt.Errorf("consuming LEFT_BRACE: %w", err)
+	}
+
+	body, err := p.block()
+	if err != nil {
+		return zero, fmt.Errorf("parsing body: %w", err)
+	}
+	return functionStmt{
+		Span:   p.spanFrom(start),
+		name:   name,
+		params: parameters,
+		body:   body,
+	}, nil
+}
+
+func (p *parser) block() ([]statement, error) {
+	var statements []statement
+	for !p.check(tokenRightBrace) && !p.isAtEnd() {
+		if stmt := p.declaration(); stmt != nil {
+			statements = append(
+				statements,
+				stmt,
+			)
+		}
+	}
+	if _, err := p.consume(tokenRightBrace, "Expect '}' after block."); err != nil {
+		return nil, fmt.Errorf("consuming RIGHT_BRACE: %w", err)
+	}
+	return statements, nil
+}
+
+func (p *parser) assignment() (expression, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("or(): %w", err)
+	}
+
+	if p.match(tokenEqual) {
+		equals := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, fmt.Errorf("assignment(): %w", err)
+		}
+
+		foo, ok := expr.(*variableExpr)
+		if ok {
+			var name token = foo.name
+			return &assignExpr{
+				Span:  joinSpans(expr.span(), value.span()),
+				name:  name,
+				value: value,
+			}, nil
+		}
+
+		// https://craftinginterpreters.com/classes.html#set-expressions
+		get, ok := expr.(*getExpr)
+		if ok {
+			return &setExpr{
+				Span:   joinSpans(expr.span(), value.span()),
+				object: get.object,
+				name:   get.name,
+				value:  value,
+			}, nil
+		}
+
+		if index, ok := expr.(*indexExpr); ok {
+			return &setIndexExpr{
+				Span:    joinSpans(expr.span(), value.span()),
+				object:  index.object,
+				bracket: index.bracket,
+				index:   index.index,
+				value:   value,
+			}, nil
+		}
+
+		// Report, but don't unwind, the parser is not confused
+		p.error(equals, CODE_INVALID_ASSIGNMENT, "Invalid assignment target.")
+	}
+
+	return expr, nil
+}
+
+func (p *parser) or() (expression, error) {
+	expr, err := p.and()
+	if err != nil {
+		return nil, fmt.Errorf("and(): %w", err)
+	}
+
+	for p.match(tokenOr) {
+		var operator token = p.previous()
+		right, err := p.and()
+		if err != nil {
+			return nil, fmt.Errorf("and(): %w", err)
+		}
+		expr = &logicalExpr{
+			Span:     joinSpans(expr.span(), right.span()),
+			left:     expr,
+			operator: operator,
+			right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) and() (expression, error) {
+	expr, err := p.equality()
+	if err != nil {
+		return nil, fmt.Errorf("equality(): %w", err)
+	}
+
+	for p.match(tokenAnd) {
+		var operator token = p.previous()
+		right, err := p.equality()
+		if err != nil {
+			return nil, fmt.Errorf("equality(): %w", err)
+		}
+		expr = &logicalExpr{
+			Span:     joinSpans(expr.span(), right.span()),
+			left:     expr,
+			operator: operator,
+			right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+// match checks if the current token matches any of the types
+// in the input arg(s). If it matches, consume the token
+// and return true
+func (p *parser) match(types ...tokenType) bool {
+	for _, tokentype := range types {
+		if p.check(tokentype) {
+			p.advance()
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) consume(tokentype tokenType, message string) (token, error) {
+	if p.check(tokentype) {
+		return p.advance(), nil
+	}
+
+	return token{}, p.error(p.peek(), CODE_SYNTAX, message)
+}
+
+// error records a syntax error at the token. It returns an error for the
+// caller to unwind with, up to declaration() which synchronizes.
+// https://craftinginterpreters.com/parsing-expressions.html#entering-panic-mode
+func (p *parser) error(token token, code string, message string) error {
+	p.diagnostics = append(p.diagnostics, newError(code, token.span(), message))
+	return fmt.Errorf("parse error at %v: %v", token.span(), message)
+}
+
+// spanFrom returns the span from the start token up to and including the
+// last consumed token
+func (p *parser) spanFrom(start token) Span {
+	return joinSpans(start.span(), p.previous().span())
+}
+
+func (p *parser) synchronize() {
+	p.advance()
+
+	for !p.isAtEnd() {
+		if p.previous().tokenType == tokenSemicolon {
+			return
+		}
+
+		switch p.peek().tokenType {
+		case tokenClass, tokenFun, tokenVar, tokenFor, tokenIf, tokenWhile, tokenPrint, tokenReturn, tokenBreak, tokenContinue, tokenThrow, tokenTry, tokenImport:
+			return
+		}
+
+		p.advance()
+	}
+}
+
+// check checks if the current token is of the token type
+// in the argument
+func (p *parser) check(tokentype tokenType) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.peek().tokenType == tokentype
+}
+
+func (p *parser) advance() token {
+	if !p.isAtEnd() {
+		p.current += 1
+	}
+	return p.previous()
+}
+
+func (p *parser) isAtEnd() bool {
+	return p.peek().tokenType == tokenEOF
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.current]
+}
+
+func (p *parser) previous() token {
+	return p.tokens[p.current-1]
+}
+
+func (p *parser) equality() (expression, error) {
+	expr, err := p.comparison()
+	if err != nil {
+		return nil, fmt.Errorf("comparison(): %w", err)
+	}
+
+	for p.match(tokenBangEqual, tokenEqualEqual) {
+		var operator token = p.previous()
+		right, err := p.comparison()
+		if err != nil {
+			return nil, fmt.Errorf("comparison(): %w", err)
+		}
+		expr = &binaryExpr{
+			Span:     joinSpans(expr.span(), right.span()),
+			left:     expr,
+			operator: operator,
+			right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) comparison() (expression, error) {
+	expr, err := p.term()
+	if err != nil {
+		return nil, fmt.Errorf("term(): %w", err)
+	}
+
+	for p.match(tokenGreater, tokenGreaterEqual, tokenLess, tokenLessEqual) {
+		var operator token = p.previous()
+		right, err := p.term()
+		if err != nil {
+			return nil, fmt.Errorf("term(): %w", err)
+		}
+		expr = &binaryExpr{
+			Span:     joinSpans(expr.span(), right.span()),
+			left:     expr,
+			operator: operator,
+			right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) term() (expression, error) {
+	expr, err := p.factor()
+	if err != nil {
+		return nil, fmt.Errorf("factor(): %w", err)
+	}
+
+	for p.match(tokenMinus, tokenPlus) {
+		var operator token = p.previous()
+		right, err := p.factor()
+		if err != nil {
+			return nil, fmt.Errorf("factor(): %w", err)
+		}
+		expr = &binaryExpr{
+			Span:     joinSpans(expr.span(), right.span()),
+			left:     expr,
+			operator: operator,
+			right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) factor() (expression, error) {
+	expr, err := p.unary()
+	if err != nil {
+		return nil, fmt.Errorf("unary(): %w", err)
+	}
+
+	for p.match(tokenSlash, tokenStar) {
+		var operator token = p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, fmt.Errorf("unary(): %w", err)
+		}
+		expr = &binaryExpr{
+			Span:     joinSpans(expr.span(), right.span()),
+			left:     expr,
+			operator: operator,
+			right:    right,
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) unary() (expression, error) {
+	if p.match(tokenBang, tokenMinus) {
+		var operator token = p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, fmt.Errorf("unary(): %w", err)
+		}
+		return &unaryExpr{
+			Span:     joinSpans(operator.span(), right.span()),
+			operator: operator,
+			right:    right,
+		}, nil
+	}
+
+	c, err := p.call()
+	if err != nil {
+		return nil, fmt.Errorf("call(): %w", err)
+	}
+	return c, nil
+}
+
+func (p *parser) finishCall(callee expression) (expression, error) {
+	var arguments []expression
+
+	if !p.check(tokenRightParen) {
+		for true {
+			if len(arguments) >= 255 {
+				p.error(p.peek(), CODE_TOO_MANY_ARGUMENTS, "Can't have more than 255 arguments.")
+			}
+			expr, err := p.expression()
+			if err != nil {
+				return nil, fmt.Errorf("expression(): %w", err)
+			}
+			arguments = append(
+				arguments,
+				expr,
+			)
+			if !p.match(tokenComma) {
+				break
+			}
+		}
+	}
+
+	paren, err := p.consume(tokenRightParen, "Expect ')' after arguments.")
+	if err != nil {
+		return nil, fmt.Errorf("consuming right paren: %w", err)
+	}
+
+	return &callExpr{
+		Span:      joinSpans(callee.span(), paren.span()),
+		callee:    callee,
+		paren:     paren,
+		arguments: arguments,
+	}, nil
+}
+
+func (p *parser) call() (expression, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, fmt.Errorf("primary(): %w", err)
+	}
+
+	for true {
+		if p.match(tokenLeftParen) {
+			tmp, err := p.finishCall(expr)
+			if err != nil {
+				return nil, fmt.Errorf("finishCall(): %w", err)
+			}
+			expr = tmp
+		} else if p.match(tokenDot) {
+			name, err := p.consume(tokenIdentifier, "Expect property name after '.'.")
+			if err != nil {
+				return nil, fmt.Errorf("consuming property name: %w", err)
+			}
+			expr = &getExpr{
+				Span:   joinSpans(expr.span(), name.span()),
+				object: expr,
+				name:   name,
+			}
+		} else if p.match(tokenLeftBracket) {
+			index, err := p.expression()
+			if err != nil {
+				return nil, fmt.Errorf("expression(): %w", err)
+			}
+			bracket, err := p.consume(tokenRightBracket, "Expect ']' after index.")
+			if err != nil {
+				return nil, fmt.Errorf("consuming right bracket: %w", err)
+			}
+			expr = &indexExpr{
+				Span:    joinSpans(expr.span(), bracket.span()),
+				object:  expr,
+				bracket: bracket,
+				index:   index,
+			}
+		} else {
+			break
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) primary() (expression, error) {
+	if p.match(tokenFalse) {
+		return &literalExpr{
+			Span:  p.previous().span(),
+			value: false,
+		}, nil
+	}
+	if p.match(tokenTrue) {
+		return &literalExpr{
+			Span:  p.previous().span(),
+			value: true,
+		}, nil
+	}
+	if p.match(tokenNil) {
+		return &literalExpr{
+			Span:  p.previous().span(),
+			value: nil,
+		}, nil
+	}
+
+	if p.match(tokenNumber, tokenString) {
+		return &literalExpr{
+			Span:  p.previous().span(),
+			value: p.previous().literal,
+		}, nil
+	}
+	if p.match(tokenInterpolation) {
+		return p.interpolation()
+	}
+
+	// https://craftinginterpreters.com/inheritance.html#syntax
+	if p.match(tokenSuper) {
+		keyword := p.previous()
+		if _, err := p.consume(tokenDot, "Expect '.' after 'super'."); err != nil {
+			return nil, fmt.Errorf("consuming dot: %w", err)
+		}
+		method, err := p.consume(tokenIdentifier, "Expect superclass method name.")
+		if err != nil {
+			return nil, fmt.Errorf("consuming superclass method name: %w", err)
+		}
+		return &superExpr{
+			Span:    p.spanFrom(keyword),
+			keyword: keyword,
+			method:  method,
+		}, nil
+	}
+
+	if p.match(tokenThis) {
+		return &thisExpr{
+			Span:    p.previous().span(),
+			keyword: p.previous(),
+		}, nil
+	}
+
+	if p.match(tokenIdentifier) {
+		return &variableExpr{
+			Span: p.previous().span(),
+			name: p.previous(),
+		}, nil
+	}
+
+	if p.match(tokenLeftParen) {
+		paren := p.previous()
+		// TODO: Consider normal errors instead of panics()
+		expr, err := p.expression()
+		if err != nil {
+			return nil, fmt.Errorf("expression(): %w", err)
+		}
+		if _, err := p.consume(tokenRightParen, "Expect ')' after expression."); err != nil {
+			return nil, fmt.Errorf("trying to consume: %w", err)
+		}
+		return &groupingExpr{
+			Span:       p.spanFrom(paren),
+			expression: expr,
+		}, nil
+	}
+
+	if p.match(tokenLeftBracket) {
+		bracket := p.previous()
+		var elements []expression
+		if !p.check(tokenRightBracket) {
+			for true {
+				element, err := p.expression()
+				if err != nil {
+					return nil, fmt.Errorf("expression(): %w", err)
+				}
+				elements = append(elements, element)
+				if !p.match(tokenComma) {
+					break
+				}
+			}
+		}
+		if _, err := p.consume(tokenRightBracket, "Expect ']' after list elements."); err != nil {
+			return nil, fmt.Errorf("consuming right bracket: %w", err)
+		}
+		return &listExpr{
+			Span:     p.spanFrom(bracket),
+			elements: elements,
+		}, nil
+	}
+
+	// A brace starting a statement is a block, so this is only reached for
+	// map literals in expressions
+	if p.match(tokenLeftBrace) {
+		brace := p.previous()
+		var keys, values []expression
+		if !p.check(tokenRightBrace) {
+			for true {
+				key, err := p.expression()
+				if err != nil {
+					return nil, fmt.Errorf("expression(): %w", err)
+				}
+				if _, err := p.consume(tokenColon, "Expect ':' after map key."); err != nil {
+					return nil, fmt.Errorf("consuming colon: %w", err)
+				}
+				value, err := p.expression()
+				if err != nil {
+					return nil, fmt.Errorf("expression(): %w", err)
+				}
+				keys = append(keys, key)
+				values = append(values, value)
+				if !p.match(tokenComma) {
+					break
+				}
+			}
+		}
+		if _, err := p.consume(tokenRightBrace, "Expect '}' after map entries."); err != nil {
+			return nil, fmt.Errorf("consuming right brace: %w", err)
+		}
+		return &mapExpr{
+			Span:   p.spanFrom(brace),
+			brace:  brace,
+			keys:   keys,
+			values: values,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("reached end of primary(): %w", p.error(p.peek(), CODE_SYNTAX, "Expect expression."))
+}
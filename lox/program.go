@@ -0,0 +1,108 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Program is a script compiled to bytecode for the VM, see Compile. It can
+// be saved to a .loxc file, and read back with ReadProgram, to skip parsing
+// and compiling it every time it runs.
+type Program struct {
+	function *objFunction
+}
+
+// Compile compiles the source to bytecode, name is used for it in error
+// messages. Errors are reported to stderr, and returned as a StaticError.
+func (i *Interpreter) Compile(name string, source string) (*Program, error) {
+	i.renderer.addSource(name, source)
+	function, diagnostics := compileSource(name, source)
+	if i.renderer.report(i.stderr, diagnostics) {
+		return nil, newStaticError(diagnostics)
+	}
+	return &Program{function: function}, nil
+}
+
+// ReadProgram reads a program written by Save. The error wraps ErrLoxcMagic,
+// ErrLoxcVersion, ErrLoxcChecksum or ErrLoxcInvalid if it isn't one that
+// can be run.
+func ReadProgram(r io.Reader) (*Program, error) {
+	function, err := readLoxc(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{function: function}, nil
+}
+
+// Save writes the program in the .loxc format
+func (p *Program) Save(w io.Writer) error {
+	return writeLoxc(w, p.function)
+}
+
+// Disassemble writes the bytecode of the program, and of every function in it
+func (p *Program) Disassemble(w io.Writer) {
+	disassembleFunction(w, p.function)
+}
+
+// Run runs the program on the VM. Globals defined by earlier programs are
+// still there, but the VM doesn't share them with Eval. Runtime errors are
+// reported to stderr and returned, like in Eval. The execution budget isn't
+// supported by the VM, so Run refuses to run if one is set.
+func (i *Interpreter) Run(program *Program) error {
+	if i.maxSteps != 0 || !i.deadline.IsZero() || i.ctx.Done() != nil {
+		return errors.New("execution budgets are only supported by Eval")
+	}
+	if i.vm == nil {
+		i.vm = i.newVM()
+	}
+
+	err := i.vm.interpret(program.function)
+	var runtimeErr RuntimeError
+	if errors.As(err, &runtimeErr) {
+		i.renderer.runtimeError(i.stderr, err)
+	}
+	return err
+}
+
+// newVM returns the VM that runs programs, with the same settings and native
+// functions as the interpreter
+func (i *Interpreter) newVM() *virtualMachine {
+	vm := newVM(i.maxDepth)
+	vm.stdout = i.stdout
+	vm.modules = newModuleLoader(i.renderer, i.stderr, i.modulePath)
+	vm.modules.disabled = i.noImports
+	vm.modules.root = i.moduleRoot
+	for name, value := range i.builtins.values {
+		vm.builtins[name] = value
+		vm.globals[name] = value
+	}
+	return vm
+}
+
+// PrintAST parses the source and writes its syntax tree in the format, one
+// of "sexpr", "json" or "dot", without running it. Syntax errors are
+// reported to stderr, and returned as a StaticError.
+func (i *Interpreter) PrintAST(w io.Writer, name string, source string, format string) error {
+	i.renderer.addSource(name, source)
+	statements, diagnostics := parseSource(name, source)
+	if i.renderer.report(i.stderr, diagnostics) {
+		return newStaticError(diagnostics)
+	}
+
+	switch format {
+	case "sexpr":
+		fmt.Fprint(w, astPrintProgram(statements))
+	case "json":
+		res, err := astJSON(statements)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, res)
+	case "dot":
+		fmt.Fprint(w, astDot(statements))
+	default:
+		return fmt.Errorf("unknown AST format %q, expected sexpr, json or dot", format)
+	}
+	return nil
+}
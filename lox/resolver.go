@@ -0,0 +1,382 @@
+package lox
+
+import (
+	"fmt"
+)
+
+// https://craftinginterpreters.com/resolving-and-binding.html#a-resolver-class
+type functionType int
+
+const (
+	functionTypeNone = functionType(iota)
+	functionTypeFunction
+	functionTypeInitializer
+	functionTypeMethod
+)
+
+// https://craftinginterpreters.com/classes.html#invalid-uses-of-this
+type classType int
+
+const (
+	classTypeNone = classType(iota)
+	classTypeClass
+	classTypeSubclass
+)
+
+type resolver struct {
+	interpreter *Interpreter
+	// Each scope maps a variable name to whether its initializer has
+	// finished resolving. The innermost scope is the last element.
+	scopes []map[string]bool
+	// declarations holds the name token every variable in the matching
+	// scope was declared with, so errors can point back at it
+	declarations    []map[string]token
+	currentFunction functionType
+	currentClass    classType
+	// loopDepth is the number of loops around the statement being resolved,
+	// in the current function
+	loopDepth   int
+	diagnostics []Diagnostic
+}
+
+func newResolver(interpreter *Interpreter) *resolver {
+	return &resolver{
+		interpreter:     interpreter,
+		currentFunction: functionTypeNone,
+		currentClass:    classTypeNone,
+	}
+}
+
+func (r *resolver) resolve(statements []statement) {
+	for _, statement := range statements {
+		r.resolveStmt(statement)
+	}
+}
+
+func (r *resolver) resolveStmt(stmt statement) {
+	switch t := stmt.(type) {
+	case blockStmt:
+		r.visitBlockStmt(t)
+	case classStmt:
+		r.visitClassStmt(t)
+	case expressionStmt:
+		r.resolveExpr(t.expression)
+	case functionStmt:
+		r.visitFunctionStmt(t)
+	case ifStmt:
+		r.visitIfStmt(t)
+	case printStmt:
+		r.resolveExpr(t.expression)
+	case returnStmt:
+		r.visitReturnStmt(t)
+	case varStmt:
+		r.visitVarStmt(t)
+	case importStmt:
+		r.declare(t.name)
+		r.define(t.name)
+	case whileStmt:
+		r.visitWhileStmt(t)
+	case throwStmt:
+		r.resolveExpr(t.value)
+	case tryStmt:
+		r.visitTryStmt(t)
+	case breakStmt:
+		r.checkInLoop(t.keyword)
+	case continueStmt:
+		r.checkInLoop(t.keyword)
+	default:
+		panic(fmt.Sprintf("resolving: unknown type %T: %v", stmt, t))
+	}
+}
+
+func (r *resolver) resolveExpr(expr expression) {
+	switch t := expr.(type) {
+	case *assignExpr:
+		r.resolveExpr(t.value)
+		r.resolveLocal(t, t.name)
+	case *binaryExpr:
+		r.resolveExpr(t.left)
+		r.resolveExpr(t.right)
+	case *callExpr:
+		r.resolveExpr(t.callee)
+		for _, argument := range t.arguments {
+			r.resolveExpr(argument)
+		}
+	case *getExpr:
+		// Properties are looked up dynamically, so only the object is resolved
+		r.resolveExpr(t.object)
+	case *groupingExpr:
+		r.resolveExpr(t.expression)
+	case *indexExpr:
+		r.resolveExpr(t.object)
+		r.resolveExpr(t.index)
+	case *interpolationExpr:
+		for _, part := range t.parts {
+			r.resolveExpr(part)
+		}
+	case *listExpr:
+		for _, element := range t.elements {
+			r.resolveExpr(element)
+		}
+	case *literalExpr:
+		// Nothing to resolve
+	case *mapExpr:
+		for n := range t.keys {
+			r.resolveExpr(t.keys[n])
+			r.resolveExpr(t.values[n])
+		}
+	case *logicalExpr:
+		r.resolveExpr(t.left)
+		r.resolveExpr(t.right)
+	case *setExpr:
+		r.resolveExpr(t.value)
+		r.resolveExpr(t.object)
+	case *setIndexExpr:
+		r.resolveExpr(t.object)
+		r.resolveExpr(t.index)
+		r.resolveExpr(t.value)
+	case *superExpr:
+		r.visitSuperExpr(t)
+	case *thisExpr:
+		r.visitThisExpr(t)
+	case *unaryExpr:
+		r.resolveExpr(t.right)
+	case *variableExpr:
+		r.visitVariableExpr(t)
+	default:
+		panic(fmt.Sprintf("resolving: unknown type %T: %v", expr, t))
+	}
+}
+
+func (r *resolver) visitBlockStmt(stmt blockStmt) {
+	r.beginScope()
+	r.resolve(stmt.statements)
+	r.endScope()
+}
+
+func (r *resolver) visitClassStmt(stmt classStmt) {
+	enclosingClass := r.currentClass
+	r.currentClass = classTypeClass
+	defer func() {
+		r.currentClass = enclosingClass
+	}()
+
+	r.declare(stmt.name)
+	r.define(stmt.name)
+
+	if stmt.superclass != nil {
+		if stmt.name.lexeme == stmt.superclass.name.lexeme {
+			r.error(stmt.superclass.name, CODE_INHERIT_FROM_SELF, "A class can't inherit from itself.")
+		}
+
+		r.currentClass = classTypeSubclass
+		r.resolveExpr(stmt.superclass)
+
+		// Methods of a subclass close over an extra environment where "super" is bound
+		// https://craftinginterpreters.com/inheritance.html#semantics
+		r.beginScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+	}
+
+	// Methods are closures over an environment where "this" is bound
+	r.beginScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+
+	for _, method := range stmt.methods {
+		declaration := functionTypeMethod
+		if method.name.lexeme == "init" {
+			declaration = functionTypeInitializer
+		}
+		r.resolveFunction(method, declaration)
+	}
+
+	r.endScope()
+
+	if stmt.superclass != nil {
+		r.endScope()
+	}
+}
+
+func (r *resolver) visitFunctionStmt(stmt functionStmt) {
+	// Define the name eagerly so the function can refer to itself recursively
+	r.declare(stmt.name)
+	r.define(stmt.name)
+
+	r.resolveFunction(stmt, functionTypeFunction)
+}
+
+func (r *resolver) visitIfStmt(stmt ifStmt) {
+	r.resolveExpr(stmt.condition)
+	r.resolveStmt(stmt.thenBranch)
+	if stmt.elseBranch != nil {
+		r.resolveStmt(stmt.elseBranch)
+	}
+}
+
+func (r *resolver) visitWhileStmt(stmt whileStmt) {
+	r.resolveExpr(stmt.condition)
+	r.loopDepth++
+	r.resolveStmt(stmt.body)
+	r.loopDepth--
+	if stmt.increment != nil {
+		r.resolveExpr(stmt.increment)
+	}
+}
+
+func (r *resolver) visitTryStmt(stmt tryStmt) {
+	r.resolveStmt(stmt.body)
+	if stmt.catch != nil {
+		// The error variable is in a scope of its own, around the catch body
+		r.beginScope()
+		r.declare(stmt.catch.name)
+		r.define(stmt.catch.name)
+		r.resolve(stmt.catch.body)
+		r.endScope()
+	}
+	if stmt.finally != nil {
+		r.resolveStmt(*stmt.finally)
+	}
+}
+
+// checkInLoop reports a break or continue that has no loop to leave
+func (r *resolver) checkInLoop(keyword token) {
+	if r.loopDepth == 0 {
+		r.error(keyword, CODE_OUTSIDE_LOOP, fmt.Sprintf("Can't use '%s' outside of a loop.", keyword.lexeme))
+	}
+}
+
+func (r *resolver) visitReturnStmt(stmt returnStmt) {
+	if r.currentFunction == functionTypeNone {
+		r.error(stmt.keyword, CODE_TOP_LEVEL_RETURN, "Can't return from top-level code.")
+	}
+
+	if stmt.value != nil {
+		if r.currentFunction == functionTypeInitializer {
+			r.error(stmt.keyword, CODE_INITIALIZER_RETURN, "Can't return a value from an initializer.")
+		}
+		r.resolveExpr(stmt.value)
+	}
+}
+
+// https://craftinginterpreters.com/inheritance.html#invalid-uses-of-super
+func (r *resolver) visitSuperExpr(expr *superExpr) {
+	switch r.currentClass {
+	case classTypeNone:
+		r.error(expr.keyword, CODE_SUPER_OUTSIDE_CLASS, "Can't use 'super' outside of a class.")
+		return
+	case classTypeClass:
+		r.error(expr.keyword, CODE_SUPER_WITHOUT_SUPERCLASS, "Can't use 'super' in a class with no superclass.")
+		return
+	}
+
+	r.resolveLocal(expr, expr.keyword)
+}
+
+// https://craftinginterpreters.com/classes.html#this
+func (r *resolver) visitThisExpr(expr *thisExpr) {
+	if r.currentClass == classTypeNone {
+		r.error(expr.keyword, CODE_THIS_OUTSIDE_CLASS, "Can't use 'this' outside of a class.")
+		return
+	}
+
+	r.resolveLocal(expr, expr.keyword)
+}
+
+// https://craftinginterpreters.com/resolving-and-binding.html#resolving-variable-declarations
+func (r *resolver) visitVarStmt(stmt varStmt) {
+	r.declare(stmt.name)
+	if stmt.initializer != nil {
+		r.resolveExpr(stmt.initializer)
+	}
+	r.define(stmt.name)
+}
+
+func (r *resolver) visitVariableExpr(expr *variableExpr) {
+	if len(r.scopes) > 0 {
+		defined, ok := r.scopes[len(r.scopes)-1][expr.name.lexeme]
+		if ok && !defined {
+			r.error(expr.name, CODE_OWN_INITIALIZER, "Can't read local variable in its own initializer.", note{
+				span:    r.declarations[len(r.declarations)-1][expr.name.lexeme].span(),
+				message: "variable declared here",
+			})
+		}
+	}
+
+	r.resolveLocal(expr, expr.name)
+}
+
+func (r *resolver) resolveFunction(function functionStmt, functionType functionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = functionType
+	// A break in the function can't leave a loop the function is declared in
+	enclosingLoopDepth := r.loopDepth
+	r.loopDepth = 0
+	defer func() {
+		r.currentFunction = enclosingFunction
+		r.loopDepth = enclosingLoopDepth
+	}()
+
+	r.beginScope()
+	for _, param := range function.params {
+		r.declare(param)
+		r.define(param)
+	}
+	r.resolve(function.body)
+	r.endScope()
+}
+
+// resolveLocal walks the scopes from the innermost and outwards, and tells the
+// interpreter how many scopes away the variable was found. If it is not found
+// we assume it is global, and leave it unresolved.
+func (r *resolver) resolveLocal(expr expression, name token) {
+	// Without an interpreter we are only checking for static errors
+	if r.interpreter == nil {
+		return
+	}
+
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.lexeme]; ok {
+			r.interpreter.resolve(expr, len(r.scopes)-1-i)
+			return
+		}
+	}
+}
+
+// error records an error at the token, resolving carries on afterwards
+func (r *resolver) error(token token, code string, message string, notes ...note) {
+	r.diagnostics = append(r.diagnostics, newError(code, token.span(), message, notes...))
+}
+
+func (r *resolver) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]bool))
+	r.declarations = append(r.declarations, make(map[string]token))
+}
+
+func (r *resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.declarations = r.declarations[:len(r.declarations)-1]
+}
+
+func (r *resolver) declare(name token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+
+	scope := r.scopes[len(r.scopes)-1]
+	declarations := r.declarations[len(r.declarations)-1]
+	if _, ok := scope[name.lexeme]; ok {
+		r.error(name, CODE_REDECLARED, "Already a variable with this name in this scope.", note{
+			span:    declarations[name.lexeme].span(),
+			message: "previous declaration here",
+		})
+	}
+	scope[name.lexeme] = false
+	declarations[name.lexeme] = name
+}
+
+func (r *resolver) define(name token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	r.scopes[len(r.scopes)-1][name.lexeme] = true
+}
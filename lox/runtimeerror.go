@@ -0,0 +1,77 @@
+package lox
+
+import (
+	"fmt"
+)
+
+type RuntimeError struct {
+	token token
+	msg   string
+	err   error
+	// trace holds the calls that were in progress when the error happened,
+	// innermost first. It is filled in when the error leaves the interpreter,
+	// or is caught.
+	trace []StackFrame
+	// thrown is set for errors raised by a throw statement, value holds
+	// the value that was thrown
+	thrown bool
+	value  any
+}
+
+// StackFrame is a call to a Lox function
+type StackFrame struct {
+	// function is the name of the function that was called
+	function string
+	// callSite is the closing parenthesis of the call
+	callSite token
+}
+
+// Function returns the name of the function that was called
+func (f StackFrame) Function() string {
+	return f.function
+}
+
+// File returns the name of the file the call is in
+func (f StackFrame) File() string {
+	return f.callSite.file
+}
+
+// Line returns the line of the call, starting at 1
+func (f StackFrame) Line() int {
+	return f.callSite.line
+}
+
+// Column returns the column of the closing parenthesis of the call, in
+// runes, starting at 1
+func (f StackFrame) Column() int {
+	return f.callSite.column
+}
+
+func (f StackFrame) String() string {
+	return fmt.Sprintf("%s() called at %v", f.function, f.callSite.span())
+}
+
+// Error formats the error as file:line:col: message, pointing at the token
+func (r RuntimeError) Error() string {
+	return fmt.Sprintf("%v: %v", r.Span(), r.msg)
+}
+
+// Message returns the error message, without the position
+func (r RuntimeError) Message() string {
+	return r.msg
+}
+
+// Span returns the part of the source the error was raised at
+func (r RuntimeError) Span() Span {
+	return r.token.span()
+}
+
+// Trace returns the calls that were in progress when the error happened,
+// innermost first. It is empty for errors raised outside of any function.
+func (r RuntimeError) Trace() []StackFrame {
+	return r.trace
+}
+
+func (r RuntimeError) Unwrap() error {
+	return r.err
+}
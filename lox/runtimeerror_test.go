@@ -0,0 +1,47 @@
+package lox
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRuntimeErrorTrace(t *testing.T) {
+	interpreter := New(WithStderr(io.Discard))
+	err := interpreter.Eval(`fun inner() {
+  return 1 + nil;
+}
+fun outer() {
+  inner();
+}
+outer();`)
+	var runtimeErr RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected a RuntimeError, got %v", err)
+	}
+	if span := runtimeErr.Span(); span.File() != "<eval#1>" || span.Start().Line() != 2 || span.Start().Column() != 12 {
+		t.Errorf("got the error at %v", span)
+	}
+
+	want := []struct {
+		function string
+		line     int
+		column   int
+	}{
+		{"inner", 5, 9},
+		{"outer", 7, 7},
+	}
+	trace := runtimeErr.Trace()
+	if len(trace) != len(want) {
+		t.Fatalf("got %d frames, want %d: %v", len(trace), len(want), trace)
+	}
+	for n, frame := range trace {
+		if frame.Function() != want[n].function || frame.Line() != want[n].line || frame.Column() != want[n].column {
+			t.Errorf("frame %d: got %s() at %d:%d, want %s() at %d:%d", n,
+				frame.Function(), frame.Line(), frame.Column(), want[n].function, want[n].line, want[n].column)
+		}
+		if frame.File() != "<eval#1>" {
+			t.Errorf("frame %d: got file %q", n, frame.File())
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var keywords = map[string]tokenType{
+	"and":      tokenAnd,
+	"as":       tokenAs,
+	"break":    tokenBreak,
+	"catch":    tokenCatch,
+	"class":    tokenClass,
+	"continue": tokenContinue,
+	"else":     tokenElse,
+	"false":    tokenFalse,
+	"finally":  tokenFinally,
+	"for":      tokenFor,
+	"fun":      tokenFun,
+	"if":       tokenIf,
+	"import":   tokenImport,
+	"nil":      tokenNil,
+	"or":       tokenOr,
+	"print":    tokenPrint,
+	"return":   tokenReturn,
+	"super":    tokenSuper,
+	"this":     tokenThis,
+	"throw":    tokenThrow,
+	"true":     tokenTrue,
+	"try":      tokenTry,
+	"var":      tokenVar,
+	"while":    tokenWhile,
+}
+
+// scanner turns the source into tokens. It works on runes, so identifiers and
+// strings can hold any UTF-8 text, and columns count runes, not bytes.
+type scanner struct {
+	// file is the name of the source, used in error messages
+	file   string
+	source string
+	tokens []token
+	// start and current are byte offsets into the source
+	start   int
+	current int
+	line    int
+	// column is the column of the next rune to be scanned
+	column int
+	// startPosition is where the lexeme being scanned starts
+	startPosition Position
+	// interpolations has an element for every ${ being scanned, holding
+	// the number of { in its expression that are not closed yet
+	interpolations []int
+	diagnostics    []Diagnostic
+}
+
+func newScanner(file string, source string) *scanner {
+	return &scanner{
+		file:   file,
+		source: source,
+		line:   1,
+		column: 1,
+	}
+}
+
+func (s *scanner) scanTokens() []token {
+
+	for !s.isAtEnd() {
+		// We are at the beginning of the next lexeme
+		s.start = s.current
+		s.startPosition = s.position()
+		s.scanToken()
+	}
+
+	s.tokens = append(
+		s.tokens,
+		newToken(tokenEOF, "", nil, s.file, s.position()),
+	)
+	return s.tokens
+}
+
+// position returns the position of the next rune to be scanned
+func (s *scanner) position() Position {
+	return Position{
+		line:   s.line,
+		column: s.column,
+		offset: s.current,
+	}
+}
+
+// newline moves to the next line, after the '\n' has been consumed
+func (s *scanner) newline() {
+	s.line++
+	s.column = 1
+}
+
+// lexemeSpan returns the span of the lexeme scanned so far
+func (s *scanner) lexemeSpan() Span {
+	return Span{
+		file:  s.file,
+		start: s.startPosition,
+		end:   s.position(),
+	}
+}
+
+// error records an error about the lexeme being scanned, and keeps scanning
+func (s *scanner) error(code string, message string) {
+	s.errorAt(s.lexemeSpan(), code, message)
+}
+
+// errorAt records an error about a part of the lexeme, like an escape sequence in a string
+func (s *scanner) errorAt(span Span, code string, message string) {
+	s.diagnostics = append(s.diagnostics, newError(code, span, message))
+}
+
+func (s *scanner) isAtEnd() bool {
+	return s.current >= len(s.source)
+}
+
+func trn[T any](b bool, el1, el2 T) T {
+	if b {
+		return el1
+	}
+	return el2
+}
+
+func (s *scanner) scanToken() {
+	c := s.advance()
+
+	switch c {
+	case '(':
+		s.addToken(tokenLeftParen)
+	case ')':
+		s.addToken(tokenRightParen)
+	case '{':
+		if n := len(s.interpolations); n > 0 {
+			s.interpolations[n-1]++
+		}
+		s.addToken(tokenLeftBrace)
+	case '}':
+		if n := len(s.interpolations); n > 0 && s.interpolations[n-1] == 0 {
+			// The end of the expression, the string goes on
+			s.interpolations = s.interpolations[:n-1]
+			s.string()
+			break
+		} else if n > 0 {
+			s.interpolations[n-1]--
+		}
+		s.addToken(tokenRightBrace)
+	case '[':
+		s.addToken(tokenLeftBracket)
+	case ']':
+		s.addToken(tokenRightBracket)
+	case ',':
+		s.addToken(tokenComma)
+	case ':':
+		s.addToken(tokenColon)
+	case '.':
+		s.addToken(tokenDot)
+	case '-':
+		s.addToken(tokenMinus)
+	case '+':
+		s.addToken(tokenPlus)
+	case ';':
+		s.addToken(tokenSemicolon)
+	case '*':
+		s.addToken(tokenStar)
+	case '!':
+		s.addToken(trn(s.match('='), tokenBangEqual, tokenBang))
+	case '=':
+		s.addToken(trn(s.match('='), tokenEqualEqual, tokenEqual))
+	case '<':
+		s.addToken(trn(s.match('='), tokenLessEqual, tokenLess))
+	case '>':
+		s.addToken(trn(s.match('='), tokenGreaterEqual, tokenGreater))
+
+	case '/':
+		if s.match('/') {
+			// A comment goes until the end of the line
+			for s.peek() != '\n' && !s.isAtEnd() {
+				s.advance()
+			}
+		} else {
+			s.addToken(tokenSlash)
+		}
+
+	case ' ', '\r', '\t':
+		// Ignore whitespace
+	case '\n':
+		s.newline()
+
+	case '"':
+		s.string()
+		break
+	case '`':
+		s.rawString()
+
+	default:
+		if isDigit(c) {
+			s.number()
+		} else if isAlpha(c) {
+			s.identifier()
+		} else {
+			s.error(CODE_UNEXPECTED_CHARACTER, "Unexpected character.")
+		}
+		break
+
+	}
+}
+
+func (s *scanner) identifier() {
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+
+	text := s.source[s.start:s.current]
+	tokentype, ok := keywords[text]
+	if !ok {
+		tokentype = tokenIdentifier
+	}
+
+	s.addToken(tokentype)
+}
+
+// string scans a string in double quotes, which can span lines, replacing
+// the escape sequences in it. A ${ in the string ends the token here, as a
+// tokenInterpolation, and the scanner goes on with the expression in it. The } that
+// ends the expression continues the string, with another call to string.
+func (s *scanner) string() {
+	var value strings.Builder
+	for s.peek() != '"' && !s.isAtEnd() {
+		c := s.advance()
+		switch c {
+		case '\n':
+			s.newline()
+			value.WriteRune(c)
+		case '\\':
+			s.escape(&value)
+		case '$':
+			if s.match('{') {
+				s.interpolations = append(s.interpolations, 0)
+				s.addToken2(tokenInterpolation, value.String())
+				return
+			}
+			value.WriteRune(c)
+		default:
+			value.WriteRune(c)
+		}
+	}
+
+	if s.isAtEnd() {
+		s.error(CODE_UNTERMINATED_STRING, "Unterminated string.")
+		return
+	}
+
+	// The closing "
+	s.advance()
+	s.addToken2(tokenString, value.String())
+}
+
+// escape scans the escape sequence after a backslash, and writes the
+// character it stands for to the string value
+func (s *scanner) escape(value *strings.Builder) {
+	// The span starts at the backslash, which is one byte
+	start := s.position()
+	start.column--
+	start.offset--
+
+	if s.isAtEnd() {
+		return
+	}
+	c := s.advance()
+	switch c {
+	case 'n':
+		value.WriteByte('\n')
+	case 't':
+		value.WriteByte('\t')
+	case 'r':
+		value.WriteByte('\r')
+	case '"', '\\', '$':
+		value.WriteRune(c)
+	case 'u':
+		s.unicodeEscape(value, start)
+	default:
+		if c == '\n' {
+			s.newline()
+		}
+		span := Span{file: s.file, start: start, end: s.position()}
+		s.errorAt(span, CODE_INVALID_ESCAPE, fmt.Sprintf("Invalid escape sequence '\\%c'.", c))
+	}
+}
+
+// unicodeEscape scans the {hex digits} of a \u{1F600} escape
+func (s *scanner) unicodeEscape(value *strings.Builder, start Position) {
+	invalid := func(message string) {
+		span := Span{file: s.file, start: start, end: s.position()}
+		s.errorAt(span, CODE_INVALID_ESCAPE, message)
+	}
+
+	if !s.match('{') {
+		invalid("Expect '{' after '\\u'.")
+		return
+	}
+	digitsStart := s.current
+	for isHexDigit(s.peek()) {
+		s.advance()
+	}
+	digits := s.source[digitsStart:s.current]
+	if !s.match('}') {
+		invalid("Expect hex digits and '}' in Unicode escape.")
+		return
+	}
+	if len(digits) == 0 || len(digits) > 6 {
+		invalid("Unicode escape must have 1 to 6 hex digits.")
+		return
+	}
+
+	code, _ := strconv.ParseUint(digits, 16, 32)
+	r := rune(code)
+	if !utf8.ValidRune(r) {
+		invalid(fmt.Sprintf("Unicode escape '\\u{%s}' is not a valid code point.", digits))
+		return
+	}
+	value.WriteRune(r)
+}
+
+// rawString scans a string in backticks. It has no escape sequences, every
+// character up to the closing backtick is part of it, newlines too.
+func (s *scanner) rawString() {
+	for s.peek() != '`' && !s.isAtEnd() {
+		if s.advance() == '\n' {
+			s.newline()
+		}
+	}
+
+	if s.isAtEnd() {
+		s.error(CODE_UNTERMINATED_STRING, "Unterminated raw string.")
+		return
+	}
+
+	s.advance()
+
+	// Trim the surrounding backticks
+	value := s.source[(s.start + 1):(s.current - 1)]
+	s.addToken2(tokenString, value)
+}
+
+func (s *scanner) match(expected rune) bool {
+	if s.isAtEnd() || s.peek() != expected {
+		return false
+	}
+	s.advance()
+	return true
+}
+
+func (s *scanner) peek() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(s.source[s.current:])
+	return r
+}
+
+func (s *scanner) peekNext() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if s.current+size >= len(s.source) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(s.source[s.current+size:])
+	return r
+}
+
+// isAlpha reports whether the rune can start an identifier, which any letter can
+func isAlpha(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
+}
+
+func isAlphaNumeric(c rune) bool {
+	return isAlpha(c) || unicode.IsDigit(c)
+}
+
+func (s *scanner) number() {
+	for isDigit(s.peek()) {
+		s.advance()
+	}
+
+	// Look for a fractional part.
+	if s.peek() == '.' && isDigit(s.peekNext()) {
+		// Consume the "."
+		s.advance()
+
+		for isDigit(s.peek()) {
+			s.advance()
+		}
+	}
+
+	fl, err := strconv.ParseFloat(s.source[s.start:s.current], 64)
+	if err != nil {
+		panic(err)
+	}
+
+	s.addToken2(tokenNumber, fl)
+}
+
+// isDigit only accepts ASCII digits, numbers are always written with them
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// advance consumes the next rune. Bytes that aren't valid UTF-8 are
+// consumed one at a time, as utf8.RuneError.
+func (s *scanner) advance() rune {
+	r, size := utf8.DecodeRuneInString(s.source[s.current:])
+	s.current += size
+	s.column++
+	return r
+}
+
+func (s *scanner) addToken(tokentype tokenType) {
+	s.addToken2(tokentype, nil)
+}
+
+func (s *scanner) addToken2(tokentype tokenType, literal any) {
+	text := s.source[s.start:s.current]
+	s.tokens = append(
+		s.tokens,
+		newToken(tokentype, text, literal, s.file, s.startPosition),
+	)
+}
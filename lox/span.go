@@ -0,0 +1,71 @@
+package lox
+
+import (
+	"fmt"
+)
+
+// Position is a point in a source file
+type Position struct {
+	// line and column start at 1, column counts runes, not bytes
+	line   int
+	column int
+	// offset is the number of bytes before the position, starting at 0
+	offset int
+}
+
+// Line returns the line of the position, starting at 1
+func (p Position) Line() int {
+	return p.line
+}
+
+// Column returns the column of the position, in runes, starting at 1
+func (p Position) Column() int {
+	return p.column
+}
+
+// Offset returns the number of bytes in the source before the position
+func (p Position) Offset() int {
+	return p.offset
+}
+
+// Span is the part of a source file from start up to, but not including, end.
+// Every token and every AST node knows its span, so errors can point at it.
+type Span struct {
+	file  string
+	start Position
+	end   Position
+}
+
+// span lets the AST nodes, which embed a Span, satisfy expression and statement
+func (s Span) span() Span {
+	return s
+}
+
+// File returns the name of the source file
+func (s Span) File() string {
+	return s.file
+}
+
+// Start returns the first position of the span
+func (s Span) Start() Position {
+	return s.start
+}
+
+// End returns the position just after the span
+func (s Span) End() Position {
+	return s.end
+}
+
+// String formats the start of the span as file:line:col
+func (s Span) String() string {
+	return fmt.Sprintf("%s:%d:%d", s.file, s.start.line, s.start.column)
+}
+
+// joinSpans returns the span covering everything from the start of the first to the end of the second
+func joinSpans(first Span, second Span) Span {
+	return Span{
+		file:  first.file,
+		start: first.start,
+		end:   second.end,
+	}
+}
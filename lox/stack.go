@@ -0,0 +1,78 @@
+package lox
+
+import (
+	"fmt"
+	"sync"
+)
+
+type stack[T any] struct {
+	data []T
+	len  int
+	mu   sync.Mutex
+}
+
+func (s *stack[T]) Push(el T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.len < len(s.data) {
+		// no need to do s.len + 1 here, since we are increment later
+		s.data[s.len] = el
+	} else {
+		s.data = append(
+			s.data,
+			el,
+		)
+	}
+	s.len += 1
+}
+
+func (s *stack[T]) Pop() T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.len == 0 {
+		panic(fmt.Errorf("tried to pop from empty stack: %+v", s))
+	}
+	s.len -= 1
+	return s.data[s.len]
+}
+
+// Peek returns the top element without popping it, and false if the stack is empty
+func (s *stack[T]) Peek() (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return s.data[s.len-1], true
+}
+
+func (s *stack[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.len
+}
+
+// Truncate pops elements until there are at most n left
+func (s *stack[T]) Truncate(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n < s.len {
+		s.len = n
+	}
+}
+
+// Items returns a copy of the elements, from the bottom of the stack to the top
+func (s *stack[T]) Items() []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]T, s.len)
+	copy(res, s.data[:s.len])
+	return res
+}
@@ -0,0 +1,160 @@
+package lox
+
+type statement interface {
+	IsStmt()
+	span() Span
+}
+
+type printStmt struct {
+	Span
+	expression expression
+}
+
+func (s printStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type expressionStmt struct {
+	Span
+	expression expression
+}
+
+func (s expressionStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type varStmt struct {
+	Span
+	name        token
+	initializer expression
+}
+
+func (s varStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+// importStmt is import "path" as name;, which defines name as the namespace
+// of the module at path
+type importStmt struct {
+	Span
+	keyword token
+	path    token
+	name    token
+}
+
+func (s importStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type blockStmt struct {
+	Span
+	statements []statement
+}
+
+func (s blockStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type ifStmt struct {
+	Span
+	condition  expression
+	thenBranch statement
+	elseBranch statement
+}
+
+func (s ifStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type whileStmt struct {
+	Span
+	condition expression
+	body      statement
+	// increment is the increment clause of a desugared for loop, or nil.
+	// It runs after the body, also when the body ends with continue.
+	increment expression
+}
+
+func (s whileStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type functionStmt struct {
+	Span
+	name   token
+	params []token
+	body   []statement
+}
+
+func (s functionStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type returnStmt struct {
+	Span
+	keyword token
+	value   expression
+}
+
+func (r returnStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type breakStmt struct {
+	Span
+	keyword token
+}
+
+func (b breakStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type continueStmt struct {
+	Span
+	keyword token
+}
+
+func (c continueStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type throwStmt struct {
+	Span
+	keyword token
+	value   expression
+}
+
+func (t throwStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+type tryStmt struct {
+	Span
+	keyword token
+	body    blockStmt
+	// catch is nil without a catch clause, and finally without a finally
+	// clause. The parser makes sure there is at least one of them.
+	catch   *catchClause
+	finally *blockStmt
+}
+
+func (t tryStmt) IsStmt() {
+	panic("shouldn't be called")
+}
+
+// catchClause is the catch (name) { body } part of a tryStmt
+type catchClause struct {
+	name token
+	body []statement
+}
+
+type classStmt struct {
+	Span
+	name       token
+	superclass *variableExpr
+	methods    []functionStmt
+}
+
+func (c classStmt) IsStmt() {
+	panic("shouldn't be called")
+}
@@ -0,0 +1,116 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringNatives are the native functions for strings, defined in both backends
+// when they are enabled, see WithStringFunctions. Strings are indexed by code
+// point, not by byte, so "é" has length 1. len works on strings too, and is
+// always defined, see nativeLen.
+var stringNatives = []*nativeFunction{
+	mustNative("substring", nativeSubstring),
+	mustNative("indexOf", nativeIndexOf),
+	mustNative("split", nativeSplit),
+	mustNative("join", nativeJoin),
+	mustNative("replace", strings.ReplaceAll),
+	mustNative("trim", strings.TrimSpace),
+	mustNative("upper", strings.ToUpper),
+	mustNative("lower", strings.ToLower),
+	mustNative("startsWith", strings.HasPrefix),
+	mustNative("endsWith", strings.HasSuffix),
+	mustNative("repeat", nativeRepeat),
+	mustNative("charAt", nativeCharAt),
+	mustNative("ord", nativeOrd),
+	mustNative("chr", nativeChr),
+}
+
+// nativeSubstring returns the code points from start up to, but not including, end
+func nativeSubstring(s string, start int, end int) (string, error) {
+	runes := []rune(s)
+	if start < 0 || end < start || end > len(runes) {
+		return "", fmt.Errorf("Substring from %d to %d is out of range for a string of length %d.", start, end, len(runes))
+	}
+	return string(runes[start:end]), nil
+}
+
+// nativeIndexOf returns the index of the first code point of the first
+// occurrence of sub in s, or -1 if there is none
+func nativeIndexOf(s string, sub string) int {
+	index := strings.Index(s, sub)
+	if index < 0 {
+		return -1
+	}
+	return utf8.RuneCountInString(s[:index])
+}
+
+// nativeSplit returns a list of the parts of s between the separators. An
+// empty separator splits s into its code points.
+func nativeSplit(s string, separator string) *LoxList {
+	parts := strings.Split(s, separator)
+	elements := make([]any, len(parts))
+	for n, part := range parts {
+		elements[n] = part
+	}
+	return NewLoxList(elements)
+}
+
+// nativeJoin returns the strings in the list, with the separator between them
+func nativeJoin(list *LoxList, separator string) (string, error) {
+	parts := make([]string, len(list.elements))
+	for n, element := range list.elements {
+		part, ok := element.(string)
+		if !ok {
+			return "", fmt.Errorf("Can't join a list with a %s at index %d, only strings.", typeName(element), n)
+		}
+		parts[n] = part
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// MAX_REPEAT_LENGTH is the length in bytes of the longest string repeat builds,
+// so a script can't make the host run out of memory with one call
+const MAX_REPEAT_LENGTH = 1 << 24
+
+func nativeRepeat(s string, count int) (string, error) {
+	if count < 0 {
+		return "", fmt.Errorf("Can't repeat a string %d times.", count)
+	}
+	// Divided instead of multiplied, which could overflow
+	if len(s) > 0 && count > MAX_REPEAT_LENGTH/len(s) {
+		return "", fmt.Errorf("Can't repeat a string %d times, the result would be longer than %d bytes.", count, MAX_REPEAT_LENGTH)
+	}
+	return strings.Repeat(s, count), nil
+}
+
+// nativeCharAt returns the code point at the index, as a string
+func nativeCharAt(s string, index int) (string, error) {
+	runes := []rune(s)
+	if index < 0 || index >= len(runes) {
+		return "", fmt.Errorf("Index %d is out of range for a string of length %d.", index, len(runes))
+	}
+	return string(runes[index]), nil
+}
+
+// nativeOrd returns the code point of a string with exactly one code point
+func nativeOrd(s string) (int, error) {
+	r, size := utf8.DecodeRuneInString(s)
+	if count := utf8.RuneCountInString(s); count != 1 {
+		return 0, fmt.Errorf("ord needs a string of length 1, got one of length %d.", count)
+	}
+	if r == utf8.RuneError && size == 1 {
+		return 0, errors.New("ord needs a valid UTF-8 string.")
+	}
+	return int(r), nil
+}
+
+// nativeChr returns the string with the code point
+func nativeChr(codePoint int) (string, error) {
+	if codePoint < 0 || codePoint > utf8.MaxRune || !utf8.ValidRune(rune(codePoint)) {
+		return "", fmt.Errorf("%d is not a valid code point.", codePoint)
+	}
+	return string(rune(codePoint)), nil
+}
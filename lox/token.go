@@ -0,0 +1,49 @@
+package lox
+
+import (
+	"fmt"
+)
+
+type token struct {
+	tokenType tokenType
+	lexeme    string
+	literal   any
+	file      string
+	// Position is the start of the lexeme in the source
+	Position
+}
+
+func newToken(tokentype tokenType, lexeme string, literal any, file string, position Position) token {
+	return token{
+		tokenType: tokentype,
+		lexeme:    lexeme,
+		literal:   literal,
+		file:      file,
+		Position:  position,
+	}
+}
+
+// span returns the part of the source the lexeme was scanned from
+func (t token) span() Span {
+	end := t.Position
+	for _, c := range t.lexeme {
+		// Columns count runes, like in the scanner
+		if c == '\n' {
+			end.line++
+			end.column = 1
+		} else {
+			end.column++
+		}
+	}
+	end.offset += len(t.lexeme)
+
+	return Span{
+		file:  t.file,
+		start: t.Position,
+		end:   end,
+	}
+}
+
+func (t token) String() string {
+	return fmt.Sprintf("(%v %v %v)", t.tokenType, t.lexeme, t.literal)
+}
@@ -0,0 +1,181 @@
+package lox
+
+type tokenType int
+
+func (t tokenType) String() string {
+
+	switch t {
+	case tokenLeftParen:
+		return "LEFT_PAREN"
+	case tokenRightParen:
+		return "RIGHT_PAREN"
+	case tokenLeftBrace:
+		return "LEFT_BRACE"
+	case tokenRightBrace:
+		return "RIGHT_BRACE"
+	case tokenLeftBracket:
+		return "LEFT_BRACKET"
+	case tokenRightBracket:
+		return "RIGHT_BRACKET"
+	case tokenComma:
+		return "COMMA"
+	case tokenColon:
+		return "COLON"
+	case tokenDot:
+		return "DOT"
+	case tokenMinus:
+		return "MINUS"
+	case tokenPlus:
+		return "PLUS"
+	case tokenSemicolon:
+		return "SEMICOLON"
+	case tokenSlash:
+		return "SLASH"
+	case tokenStar:
+		return "STAR"
+
+	case tokenBang:
+		return "BANG"
+	case tokenBangEqual:
+		return "BANG_EQUAL"
+	case tokenEqual:
+		return "EQUAL"
+	case tokenEqualEqual:
+		return "EQUAL_EQUAL"
+	case tokenGreater:
+		return "GREATER"
+	case tokenGreaterEqual:
+		return "GREATER_EQUAL"
+	case tokenLess:
+		return "LESS"
+	case tokenLessEqual:
+		return "LESS_EQUAL"
+
+	case tokenIdentifier:
+		return "IDENTIFIER"
+	case tokenString:
+		return "STRING"
+	case tokenInterpolation:
+		return "INTERPOLATION"
+	case tokenNumber:
+		return "NUMBER"
+
+	case tokenAnd:
+		return "AND"
+	case tokenAs:
+		return "AS"
+	case tokenBreak:
+		return "BREAK"
+	case tokenCatch:
+		return "CATCH"
+	case tokenClass:
+		return "CLASS"
+	case tokenContinue:
+		return "CONTINUE"
+	case tokenElse:
+		return "ELSE"
+	case tokenFalse:
+		return "FALSE"
+	case tokenFinally:
+		return "FINALLY"
+	case tokenFun:
+		return "FUN"
+	case tokenFor:
+		return "FOR"
+	case tokenIf:
+		return "IF"
+	case tokenImport:
+		return "IMPORT"
+	case tokenNil:
+		return "NIL"
+	case tokenOr:
+		return "OR"
+	case tokenPrint:
+		return "PRINT"
+	case tokenReturn:
+		return "RETURN"
+	case tokenSuper:
+		return "SUPER"
+	case tokenThis:
+		return "THIS"
+	case tokenThrow:
+		return "THROW"
+	case tokenTrue:
+		return "TRUE"
+	case tokenTry:
+		return "TRY"
+	case tokenVar:
+		return "VAR"
+	case tokenWhile:
+		return "WHILE"
+
+	case tokenEOF:
+		return "EOF"
+
+	default:
+		return "Unknown"
+	}
+}
+
+// https://craftinginterpreters.com/scanning.html#token-type
+const (
+	tokenLeftParen = tokenType(iota)
+	tokenRightParen
+	tokenLeftBrace
+	tokenRightBrace
+	tokenLeftBracket
+	tokenRightBracket
+	tokenComma
+	tokenColon
+	tokenDot
+	tokenMinus
+	tokenPlus
+	tokenSemicolon
+	tokenSlash
+	tokenStar
+
+	// One or two character tokens
+	tokenBang
+	tokenBangEqual
+	tokenEqual
+	tokenEqualEqual
+	tokenGreater
+	tokenGreaterEqual
+	tokenLess
+	tokenLessEqual
+
+	// Literals
+	tokenIdentifier
+	tokenString
+	// tokenInterpolation is the part of an interpolated string before a ${...}
+	tokenInterpolation
+	tokenNumber
+
+	// Keywords
+	tokenAnd
+	tokenAs
+	tokenBreak
+	tokenCatch
+	tokenClass
+	tokenContinue
+	tokenElse
+	tokenFalse
+	tokenFinally
+	tokenFun
+	tokenFor
+	tokenIf
+	tokenImport
+	tokenNil
+	tokenOr
+	tokenPrint
+	tokenReturn
+	tokenSuper
+	tokenThis
+	tokenThrow
+	tokenTrue
+	tokenTry
+	tokenVar
+	tokenWhile
+
+	tokenEOF
+)
@@ -0,0 +1,739 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// https://craftinginterpreters.com/a-virtual-machine.html
+
+// https://craftinginterpreters.com/calls-and-functions.html#call-frames
+type callFrame struct {
+	closure *objClosure
+	ip      int
+	// slots is the index of the first stack slot the function can use
+	slots int
+}
+
+func (f *callFrame) readByte() byte {
+	b := f.closure.function.chunk.code[f.ip]
+	f.ip++
+	return b
+}
+
+func (f *callFrame) readShort() int {
+	code := f.closure.function.chunk.code
+	f.ip += 2
+	return int(code[f.ip-2])<<8 | int(code[f.ip-1])
+}
+
+func (f *callFrame) readConstant() any {
+	return f.closure.function.chunk.constants[f.readShort()]
+}
+
+func (f *callFrame) readString() string {
+	return f.readConstant().(string)
+}
+
+// handler is where execution goes on when a runtime error is raised in the
+// body of a try statement. frames and stackTop are the number of call frames
+// and values on the stack when the try began, everything above them is
+// dropped before jumping to ip in the frame that ran the try.
+type handler struct {
+	frames   int
+	stackTop int
+	ip       int
+}
+
+type virtualMachine struct {
+	// frames never grows beyond its capacity, one frame for the script and
+	// maxDepth for function calls, so pointers into it stay valid
+	frames   []callFrame
+	maxDepth int
+	stack    []any
+	// globals holds the globals of the script, and builtins the native
+	// functions, which the globals of the script start with and modules
+	// see through
+	globals  map[string]any
+	builtins map[string]any
+	// openUpvalues is sorted by stack slot, the topmost slot first
+	openUpvalues *objUpvalue
+	// handlers holds the try statements being run, the innermost one is the last
+	handlers []handler
+	// stdout is where print statements write to
+	stdout io.Writer
+
+	// modules runs the modules imported by scripts. base is the number of
+	// frames below the module being run, run returns once it is back there.
+	modules *moduleLoader
+	base    int
+}
+
+// newVM returns a VM where calls can nest maxDepth deep before a stack overflow
+func newVM(maxDepth int) *virtualMachine {
+	vm := &virtualMachine{
+		frames:   make([]callFrame, 0, maxDepth+1),
+		maxDepth: maxDepth,
+		globals:  make(map[string]any),
+		builtins: make(map[string]any),
+		stdout:   os.Stdout,
+		modules:  newModuleLoader(newRenderer(), os.Stderr, nil),
+	}
+
+	vm.builtins["clock"] = &clock{}
+	vm.globals["clock"] = &clock{}
+	vm.defineNatives(append(listNatives, mapNatives...))
+	return vm
+}
+
+// defineNatives defines the native functions for the script and the modules it imports.
+// Natives are shared with the tree-walking interpreter through LoxCallable. The VM has
+// no Interpreter to hand them, so they get nil.
+func (vm *virtualMachine) defineNatives(natives []*nativeFunction) {
+	for _, native := range natives {
+		vm.builtins[native.name] = native
+		vm.globals[native.name] = native
+	}
+}
+
+// interpret runs the compiled script, and stops at the first RuntimeError, which is returned.
+// A StaticError is returned if a module it imports has errors, they are already reported.
+// The bytecode of .loxc files is checked before it is loaded, but not how it uses the stack,
+// so bytecode that makes the VM panic is reported with an error wrapping ErrLoxcInvalid.
+func (vm *virtualMachine) interpret(function *objFunction) (err error) {
+	defer vm.modules.run(function.chunk.file)()
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr, ok := r.(runtime.Error)
+			if !ok {
+				panic(r)
+			}
+			vm.resetStack()
+			err = fmt.Errorf("%w: %v", ErrLoxcInvalid, panicErr)
+		}
+	}()
+	closure := newObjClosure(function)
+	closure.globals = vm.globals
+	vm.push(closure)
+
+	err = vm.call(closure, 0)
+	if err == nil {
+		err = vm.run()
+	}
+	if err != nil {
+		vm.resetStack()
+		var trgt RuntimeError
+		if errors.As(err, &trgt) {
+			return trgt
+		}
+		var staticErr StaticError
+		if errors.As(err, &staticErr) {
+			return staticErr
+		}
+		panic(err)
+	}
+	return nil
+}
+
+func (vm *virtualMachine) resetStack() {
+	vm.stack = vm.stack[:0]
+	vm.frames = vm.frames[:0]
+	vm.openUpvalues = nil
+	vm.handlers = vm.handlers[:0]
+}
+
+func (vm *virtualMachine) push(value any) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *virtualMachine) pop() any {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *virtualMachine) peek(distance int) any {
+	return vm.stack[len(vm.stack)-1-distance]
+}
+
+// runtimeError builds a RuntimeError for the instruction currently being executed.
+// The VM has no tokens, so we rebuild the one the tree-walking interpreter would
+// have reported from the token type, the lexeme and the line table.
+func (vm *virtualMachine) runtimeError(tokenType tokenType, lexeme string, msg string) error {
+	return RuntimeError{
+		token: vm.token(tokenType, lexeme),
+		msg:   msg,
+		trace: vm.stackTrace(),
+	}
+}
+
+// token rebuilds the token the instruction currently being executed was compiled from
+func (vm *virtualMachine) token(tokenType tokenType, lexeme string) token {
+	frame := &vm.frames[len(vm.frames)-1]
+	chunk := frame.closure.function.chunk
+	return newToken(tokenType, lexeme, nil, chunk.file, chunk.positions[frame.ip-1])
+}
+
+// stackTrace returns a StackFrame for every function call in progress, innermost
+// first, like the tree-walking interpreter. The call site is the OP_CALL the
+// caller is executing, which has the position of the closing parenthesis.
+func (vm *virtualMachine) stackTrace() []StackFrame {
+	var trace []StackFrame
+	for i := len(vm.frames) - 1; i > 0; i-- {
+		if vm.frames[i].closure.function.name == "" {
+			// Running an imported module is not a call
+			continue
+		}
+		caller := &vm.frames[i-1]
+		chunk := caller.closure.function.chunk
+		trace = append(trace, StackFrame{
+			function: vm.frames[i].closure.function.name,
+			callSite: newToken(tokenRightParen, ")", nil, chunk.file, chunk.positions[caller.ip-1]),
+		})
+	}
+	return trace
+}
+
+// operatorToken returns the token type and lexeme of the operator an opcode was compiled from
+func operatorToken(op opCode) (tokenType, string) {
+	switch op {
+	case opGreater:
+		return tokenGreater, ">"
+	case opGreaterEqual:
+		return tokenGreaterEqual, ">="
+	case opLess:
+		return tokenLess, "<"
+	case opLessEqual:
+		return tokenLessEqual, "<="
+	case opAdd:
+		return tokenPlus, "+"
+	case opSubtract, opNegate:
+		return tokenMinus, "-"
+	case opMultiply:
+		return tokenStar, "*"
+	case opDivide:
+		return tokenSlash, "/"
+	default:
+		panic(fmt.Sprintf("operatorToken: %v is not an operator", op))
+	}
+}
+
+// run runs the code until the script returns, or a runtime error is raised
+// that no try statement catches
+func (vm *virtualMachine) run() error {
+	for {
+		err := vm.execute()
+		if err == nil {
+			return nil
+		}
+		if !vm.catch(err) {
+			return err
+		}
+	}
+}
+
+// catch unwinds to the innermost try statement, and reports whether there
+// was one. The handler gets the RuntimeError on top of the stack. Try
+// statements around the import of the module being run are left to the
+// run that imported it.
+func (vm *virtualMachine) catch(err error) bool {
+	runtimeErr, ok := isCatchable(err)
+	if !ok || len(vm.handlers) == 0 || vm.handlers[len(vm.handlers)-1].frames <= vm.base {
+		return false
+	}
+	handler := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	vm.closeUpvalues(handler.stackTop)
+	vm.stack = vm.stack[:handler.stackTop]
+	vm.frames = vm.frames[:handler.frames]
+	vm.frames[len(vm.frames)-1].ip = handler.ip
+	vm.push(runtimeErr)
+	return true
+}
+
+// execute runs instructions until the script returns or an error is raised
+func (vm *virtualMachine) execute() error {
+	frame := &vm.frames[len(vm.frames)-1]
+
+	for {
+		op := opCode(frame.readByte())
+
+		switch op {
+		case opConstant:
+			vm.push(frame.readConstant())
+		case opNil:
+			vm.push(nil)
+		case opTrue:
+			vm.push(true)
+		case opFalse:
+			vm.push(false)
+		case opPop:
+			vm.pop()
+
+		case opGetLocal:
+			slot := int(frame.readByte())
+			vm.push(vm.stack[frame.slots+slot])
+		case opSetLocal:
+			slot := int(frame.readByte())
+			vm.stack[frame.slots+slot] = vm.peek(0)
+		case opGetGlobal:
+			name := frame.readString()
+			value, ok := frame.closure.globals[name]
+			if !ok {
+				value, ok = vm.builtins[name]
+			}
+			if !ok {
+				return vm.runtimeError(tokenIdentifier, name, fmt.Sprintf("Undefined variable '%s'.", name))
+			}
+			vm.push(value)
+		case opDefineGlobal:
+			name := frame.readString()
+			frame.closure.globals[name] = vm.pop()
+		case opSetGlobal:
+			name := frame.readString()
+			if _, ok := frame.closure.globals[name]; !ok {
+				return vm.runtimeError(tokenIdentifier, name,
+					fmt.Sprintf("Tried to assign undefined variable: Undefined variable '%s'.", name))
+			}
+			frame.closure.globals[name] = vm.peek(0)
+		case opGetUpvalue:
+			upvalue := frame.closure.upvalues[frame.readByte()]
+			if upvalue.open {
+				vm.push(vm.stack[upvalue.slot])
+			} else {
+				vm.push(upvalue.closed)
+			}
+		case opSetUpvalue:
+			upvalue := frame.closure.upvalues[frame.readByte()]
+			if upvalue.open {
+				vm.stack[upvalue.slot] = vm.peek(0)
+			} else {
+				upvalue.closed = vm.peek(0)
+			}
+		case opGetProperty:
+			name := frame.readString()
+			if loxError, ok := vm.peek(0).(*LoxError); ok {
+				value, ok := loxError.property(name)
+				if !ok {
+					return vm.runtimeError(tokenIdentifier, name, fmt.Sprintf("Undefined property '%s'.", name))
+				}
+				vm.pop()
+				vm.push(value)
+				break
+			}
+			if module, ok := vm.peek(0).(*LoxModule); ok {
+				value, err := module.get(vm.token(tokenIdentifier, name))
+				if err != nil {
+					return vm.runtimeError(tokenIdentifier, name, err.(RuntimeError).msg)
+				}
+				vm.pop()
+				vm.push(value)
+				break
+			}
+			instance, ok := vm.peek(0).(*objInstance)
+			if !ok {
+				return vm.runtimeError(tokenIdentifier, name, "Only instances have properties.")
+			}
+
+			// Fields shadow methods
+			if value, ok := instance.fields[name]; ok {
+				vm.pop()
+				vm.push(value)
+				break
+			}
+			if err := vm.bindMethod(instance.class, name); err != nil {
+				return err
+			}
+		case opSetProperty:
+			name := frame.readString()
+			instance, ok := vm.peek(1).(*objInstance)
+			if !ok {
+				return vm.runtimeError(tokenIdentifier, name, "Only instances have fields.")
+			}
+
+			instance.fields[name] = vm.peek(0)
+			value := vm.pop()
+			vm.pop()
+			vm.push(value)
+		case opGetSuper:
+			name := frame.readString()
+			superclass := vm.pop().(*objClass)
+			if err := vm.bindMethod(superclass, name); err != nil {
+				return err
+			}
+
+		case opList:
+			count := frame.readShort()
+			elements := make([]any, count)
+			copy(elements, vm.stack[len(vm.stack)-count:])
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			vm.push(NewLoxList(elements))
+		case opMap:
+			// The keys and values are on the stack in pairs, in the order they were written
+			count := frame.readShort()
+			entries := vm.stack[len(vm.stack)-2*count:]
+			m := NewLoxMap()
+			for n := 0; n < count; n++ {
+				if err := m.set(entries[2*n], entries[2*n+1]); err != nil {
+					return vm.runtimeError(tokenLeftBrace, "{", err.Error())
+				}
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2*count]
+			vm.push(m)
+		case opInterpolate:
+			// The parts are on the stack in order, each is printed like print does
+			count := frame.readShort()
+			var builder strings.Builder
+			for _, part := range vm.stack[len(vm.stack)-count:] {
+				builder.WriteString(stringify(part))
+			}
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			vm.push(builder.String())
+		case opGetIndex:
+			value, err := getIndex(vm.peek(1), vm.peek(0))
+			if err != nil {
+				return vm.runtimeError(tokenRightBracket, "]", err.Error())
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2]
+			vm.push(value)
+		case opSetIndex:
+			value := vm.peek(0)
+			if err := setIndex(vm.peek(2), vm.peek(1), value); err != nil {
+				return vm.runtimeError(tokenRightBracket, "]", err.Error())
+			}
+			vm.stack = vm.stack[:len(vm.stack)-3]
+			vm.push(value)
+
+		case opEqual:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(isEqual(a, b))
+		case opNotEqual:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(!isEqual(a, b))
+		case opGreater, opGreaterEqual, opLess, opLessEqual, opSubtract, opMultiply, opDivide:
+			b, bok := vm.peek(0).(float64)
+			a, aok := vm.peek(1).(float64)
+			if !aok || !bok {
+				tokenType, lexeme := operatorToken(op)
+				return vm.runtimeError(tokenType, lexeme, "Operand must be a number")
+			}
+			vm.pop()
+			vm.pop()
+
+			switch op {
+			case opGreater:
+				vm.push(a > b)
+			case opGreaterEqual:
+				vm.push(a >= b)
+			case opLess:
+				vm.push(a < b)
+			case opLessEqual:
+				vm.push(a <= b)
+			case opSubtract:
+				vm.push(a - b)
+			case opMultiply:
+				vm.push(a * b)
+			case opDivide:
+				if b == 0.0 {
+					return vm.runtimeError(tokenSlash, "/", "divide by zero")
+				}
+				vm.push(a / b)
+			}
+		case opAdd:
+			// Plus works for both numbers and strings
+			right := vm.pop()
+			left := vm.pop()
+
+			if l, ok := left.(float64); ok {
+				if r, ok := right.(float64); ok {
+					vm.push(l + r)
+					break
+				}
+			}
+			if l, ok := left.(string); ok {
+				if r, ok := right.(string); ok {
+					vm.push(l + r)
+					break
+				}
+			}
+			return vm.runtimeError(tokenPlus, "+",
+				fmt.Sprintf("Operands must be two numbers or two strings, got %s and %s.",
+					typeName(left), typeName(right)))
+		case opNot:
+			vm.push(!isTruthy(vm.pop()))
+		case opNegate:
+			value, ok := vm.peek(0).(float64)
+			if !ok {
+				tokenType, lexeme := operatorToken(op)
+				return vm.runtimeError(tokenType, lexeme, "Operand must be a number")
+			}
+			vm.pop()
+			vm.push(-value)
+
+		case opPrint:
+			fmt.Fprintln(vm.stdout, stringify(vm.pop()))
+		case opJump:
+			offset := frame.readShort()
+			frame.ip += offset
+		case opJumpIfFalse:
+			offset := frame.readShort()
+			if !isTruthy(vm.peek(0)) {
+				frame.ip += offset
+			}
+		case opLoop:
+			offset := frame.readShort()
+			frame.ip -= offset
+		case opCall:
+			argCount := int(frame.readByte())
+			if err := vm.callValue(vm.peek(argCount), argCount); err != nil {
+				return err
+			}
+			frame = &vm.frames[len(vm.frames)-1]
+		case opClosure:
+			function := frame.readConstant().(*objFunction)
+			closure := newObjClosure(function)
+			closure.globals = frame.closure.globals
+			vm.push(closure)
+
+			for i := range closure.upvalues {
+				isLocal := frame.readByte()
+				index := int(frame.readByte())
+				if isLocal == 1 {
+					closure.upvalues[i] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.upvalues[i] = frame.closure.upvalues[index]
+				}
+			}
+		case opCloseUpvalue:
+			vm.closeUpvalues(len(vm.stack) - 1)
+			vm.pop()
+		case opReturn:
+			result := vm.pop()
+			vm.closeUpvalues(frame.slots)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+
+			if len(vm.frames) == vm.base {
+				// Pop the closure of the top-level script, or the module being run
+				vm.pop()
+				return nil
+			}
+
+			vm.stack = vm.stack[:frame.slots]
+			vm.push(result)
+			frame = &vm.frames[len(vm.frames)-1]
+
+		case opClass:
+			vm.push(newObjClass(frame.readString()))
+		case opInherit:
+			// The operand is only used to name the superclass in errors
+			name := frame.readString()
+			superclass, ok := vm.peek(1).(*objClass)
+			if !ok {
+				return vm.runtimeError(tokenIdentifier, name, "Superclass must be a class.")
+			}
+
+			// Copy down inheritance, methods defined in the subclass later override these
+			// https://craftinginterpreters.com/superclasses.html#inheriting-methods
+			subclass := vm.peek(0).(*objClass)
+			for name, method := range superclass.methods {
+				subclass.methods[name] = method
+			}
+			vm.pop()
+		case opMethod:
+			name := frame.readString()
+			method := vm.peek(0).(*objClosure)
+			class := vm.peek(1).(*objClass)
+			class.methods[name] = method
+			vm.pop()
+
+		case opTry:
+			offset := frame.readShort()
+			vm.handlers = append(vm.handlers, handler{
+				frames:   len(vm.frames),
+				stackTop: len(vm.stack),
+				ip:       frame.ip + offset,
+			})
+		case opEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case opCatch:
+			// Replace the caught error with the value the catch clause gets
+			vm.stack[len(vm.stack)-1] = caughtValue(vm.peek(0).(RuntimeError))
+		case opThrow:
+			value := vm.pop()
+			if pending, ok := value.(RuntimeError); ok {
+				// Raised again after the finally clause ran
+				return pending
+			}
+			err := throwError(vm.token(tokenThrow, "throw"), value)
+			if err.trace == nil {
+				err.trace = vm.stackTrace()
+			}
+			return err
+		case opImport:
+			path := frame.readString()
+			module, err := vm.modules.load(vm.token(tokenImport, "import"), path, vm.runModule)
+			if err != nil {
+				// Errors raised by the module have their trace already, the
+				// ones about the import itself get the trace of the import
+				var runtimeErr RuntimeError
+				if errors.As(err, &runtimeErr) && runtimeErr.trace == nil {
+					runtimeErr.trace = vm.stackTrace()
+					return runtimeErr
+				}
+				return err
+			}
+			vm.push(module)
+
+		default:
+			panic(fmt.Sprintf("run: unknown opcode %v", op))
+		}
+	}
+}
+
+// runModule compiles and runs the source of an imported module with its own
+// globals, and returns them. Modules only see the native functions of the script.
+func (vm *virtualMachine) runModule(file string, source string) (map[string]any, error) {
+	function, diagnostics := compileSource(file, source)
+	if vm.modules.renderer.report(vm.modules.stderr, diagnostics) {
+		return nil, newStaticError(diagnostics)
+	}
+
+	closure := newObjClosure(function)
+	closure.globals = make(map[string]any)
+	vm.push(closure)
+	if err := vm.call(closure, 0); err != nil {
+		return nil, err
+	}
+
+	base := vm.base
+	vm.base = len(vm.frames) - 1
+	defer func() {
+		vm.base = base
+	}()
+	if err := vm.run(); err != nil {
+		return nil, err
+	}
+	return closure.globals, nil
+}
+
+// https://craftinginterpreters.com/calls-and-functions.html#calling-functions
+func (vm *virtualMachine) callValue(callee any, argCount int) error {
+	switch callee := callee.(type) {
+	case *objBoundMethod:
+		vm.stack[len(vm.stack)-argCount-1] = callee.receiver
+		return vm.call(callee.method, argCount)
+	case *objClass:
+		vm.stack[len(vm.stack)-argCount-1] = newObjInstance(callee)
+		if initializer, ok := callee.methods["init"]; ok {
+			return vm.call(initializer, argCount)
+		}
+		if argCount != 0 {
+			return vm.runtimeError(tokenRightParen, ")",
+				fmt.Sprintf("Expected %d arguments but got %d.", 0, argCount))
+		}
+		return nil
+	case *objClosure:
+		return vm.call(callee, argCount)
+	case LoxCallable:
+		if msg := arityError(callee, argCount); msg != "" {
+			return vm.runtimeError(tokenRightParen, ")", msg)
+		}
+
+		arguments := make([]any, argCount)
+		copy(arguments, vm.stack[len(vm.stack)-argCount:])
+		result, err := callee.Call(nil, arguments)
+		if err != nil {
+			return vm.runtimeError(tokenRightParen, ")", err.Error())
+		}
+
+		vm.stack = vm.stack[:len(vm.stack)-argCount-1]
+		vm.push(result)
+		return nil
+	default:
+		return vm.runtimeError(tokenRightParen, ")", "Can only call functions and classes.")
+	}
+}
+
+func (vm *virtualMachine) call(closure *objClosure, argCount int) error {
+	if argCount != closure.function.arity {
+		return vm.runtimeError(tokenRightParen, ")",
+			fmt.Sprintf("Expected %d arguments but got %d.", closure.function.arity, argCount))
+	}
+
+	if len(vm.frames) == vm.maxDepth+1 {
+		// The interpreter has pushed the frame for the call before it finds out
+		// there is no room for it, so the failed call is in its stack trace too
+		err := vm.runtimeError(tokenRightParen, ")", "Stack overflow.").(RuntimeError)
+		err.trace = append([]StackFrame{{function: closure.function.name, callSite: err.token}}, err.trace...)
+		return err
+	}
+
+	vm.frames = append(vm.frames, callFrame{
+		closure: closure,
+		ip:      0,
+		slots:   len(vm.stack) - argCount - 1,
+	})
+	return nil
+}
+
+// bindMethod replaces the instance on top of the stack with the method bound to it
+// https://craftinginterpreters.com/methods-and-initializers.html#bound-methods
+func (vm *virtualMachine) bindMethod(class *objClass, name string) error {
+	method, ok := class.methods[name]
+	if !ok {
+		return vm.runtimeError(tokenIdentifier, name, fmt.Sprintf("Undefined property '%s'.", name))
+	}
+
+	bound := &objBoundMethod{
+		receiver: vm.peek(0),
+		method:   method,
+	}
+	vm.pop()
+	vm.push(bound)
+	return nil
+}
+
+// captureUpvalue reuses the open upvalue for the slot if a closure already captured it
+// https://craftinginterpreters.com/closures.html#tracking-open-upvalues
+func (vm *virtualMachine) captureUpvalue(slot int) *objUpvalue {
+	var prevUpvalue *objUpvalue = nil
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		prevUpvalue = upvalue
+		upvalue = upvalue.next
+	}
+
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	createdUpvalue := &objUpvalue{
+		slot: slot,
+		open: true,
+		next: upvalue,
+	}
+	if prevUpvalue == nil {
+		vm.openUpvalues = createdUpvalue
+	} else {
+		prevUpvalue.next = createdUpvalue
+	}
+	return createdUpvalue
+}
+
+// closeUpvalues moves every captured variable at or above the slot off the stack
+// https://craftinginterpreters.com/closures.html#closing-upvalues
+func (vm *virtualMachine) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.closed = vm.stack[upvalue.slot]
+		upvalue.open = false
+		vm.openUpvalues = upvalue.next
+	}
+}
@@ -1,6 +0,0 @@
-package main
-
-type LoxCallable interface {
-	Arity() int
-	Call(interpreter *Interpreter, arguments []any) (any, error)
-}
@@ -1,10 +1,289 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"glox/lox"
+
 	"github.com/pkg/profile"
 )
 
+// cli is the glox command line tool. Scripts run on the tree-walking
+// interpreter, or on the VM with -backend=vm. Errors are printed to stdout,
+// like the output of the script.
+type cli struct {
+	backend     string
+	interpreter *lox.Interpreter
+}
+
 func main() {
 	defer profile.Start(profile.ProfilePath(".")).Stop()
-	lmain()
+
+	flags := flag.NewFlagSet("glox", flag.ExitOnError)
+	colorMode := flags.String("color", "auto", `color the error messages, one of "auto", "always" or "never"`)
+	maxDepth := flags.Int("max-depth", lox.DEFAULT_MAX_DEPTH, "how deep function calls can nest before a stack overflow")
+	maxSteps := flags.Int("max-steps", 0, "stop the script after this many steps, 0 means no limit (tree backend only)")
+	timeout := flags.Duration("timeout", 0, "stop the script after this long, 0 means no timeout (tree backend only)")
+	backend := flags.String("backend", "tree", `execution backend, either "tree" for the tree-walking interpreter or "vm" for the bytecode VM`)
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: glox [flags] [script]")
+		fmt.Fprintln(flags.Output(), "       glox [flags] run script.lox|script.loxc")
+		fmt.Fprintln(flags.Output(), "       glox compile [-o output.loxc] script.lox")
+		fmt.Fprintln(flags.Output(), "       glox disasm script.lox|script.loxc")
+		fmt.Fprintln(flags.Output(), "       glox ast [--format=sexpr|json|dot] script.lox")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if *backend != "tree" && *backend != "vm" {
+		fmt.Printf("Unknown backend %q\n", *backend)
+		os.Exit(64)
+	}
+	if *maxDepth < 1 || *maxDepth > lox.MAX_DEPTH_LIMIT {
+		fmt.Printf("-max-depth must be between 1 and %d, got %d\n", lox.MAX_DEPTH_LIMIT, *maxDepth)
+		os.Exit(64)
+	}
+
+	if *backend == "vm" && (*maxSteps != 0 || *timeout != 0) {
+		fmt.Println("-max-steps and -timeout are only supported by the tree backend")
+		os.Exit(64)
+	}
+
+	color, err := useColor(*colorMode)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+
+	var deadline time.Time
+	if *timeout > 0 {
+		deadline = time.Now().Add(*timeout)
+	}
+	c := &cli{
+		backend: *backend,
+		interpreter: lox.New(
+			lox.WithMaxDepth(*maxDepth),
+			lox.WithMaxSteps(*maxSteps),
+			lox.WithDeadline(deadline),
+			lox.WithStderr(os.Stdout),
+			lox.WithColor(color),
+			// Imported modules are also looked up in the directories in GLOX_PATH
+			lox.WithModulePath(filepath.SplitList(os.Getenv("GLOX_PATH"))...),
+			lox.WithStringFunctions(),
+		),
+	}
+
+	switch {
+	case flags.NArg() == 2 && flags.Arg(0) == "disasm":
+		c.disasmFile(flags.Arg(1))
+	case flags.NArg() >= 1 && flags.Arg(0) == "compile":
+		c.compileCommand(flags.Args()[1:])
+	case flags.NArg() >= 1 && flags.Arg(0) == "ast":
+		c.astCommand(flags.Args()[1:])
+	case flags.NArg() == 2 && flags.Arg(0) == "run":
+		c.runFile(flags.Arg(1))
+	case flags.NArg() > 1:
+		flags.Usage()
+		os.Exit(64)
+	case flags.NArg() == 1:
+		c.runFile(flags.Arg(0))
+	default:
+		c.runPrompt()
+	}
+}
+
+// useColor returns whether to color the error messages in the color mode,
+// one of "auto", "always" or "never". auto uses color when printing to a
+// terminal, unless NO_COLOR is set.
+func useColor(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		return isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb", nil
+	default:
+		return false, fmt.Errorf("unknown color mode %q, expected auto, always or never", mode)
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// isCompiled reports whether the file holds precompiled bytecode
+func isCompiled(path string) bool {
+	return filepath.Ext(path) == ".loxc"
+}
+
+func readSource(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func (c *cli) runFile(path string) {
+	// Precompiled scripts skip the front end, and always run on the VM
+	if isCompiled(path) {
+		if status := c.runProgram(path, loadCompiled(path)); status != 0 {
+			os.Exit(status)
+		}
+		return
+	}
+
+	if status := c.run(path, readSource(path)); status != 0 {
+		os.Exit(status)
+	}
+}
+
+func (c *cli) runPrompt() {
+	s := bufio.NewScanner(os.Stdin)
+
+	fmt.Printf("> ")
+	for n := 1; s.Scan(); n++ {
+		// Every line is a source of its own, so errors in functions
+		// declared on earlier lines still show the right one
+		c.run(fmt.Sprintf("<stdin#%d>", n), s.Text())
+		fmt.Printf("> ")
+	}
+}
+
+// run runs the source code, file is the name used for it in error messages.
+// It returns the exit status: 65 for syntax and resolution errors, 70 for
+// runtime errors and 0 if all went well. The errors are already reported.
+func (c *cli) run(file string, source string) int {
+	if c.backend == "vm" {
+		program, err := c.interpreter.Compile(file, source)
+		if err != nil {
+			return 65
+		}
+		return c.runProgram(file, program)
+	}
+	return exitStatus(c.interpreter.EvalSource(file, source))
+}
+
+// runProgram runs the compiled script on the VM, and returns the exit status like run
+func (c *cli) runProgram(file string, program *lox.Program) int {
+	err := c.interpreter.Run(program)
+	if errors.Is(err, lox.ErrLoxcInvalid) {
+		fmt.Printf("Could not run %s: %v\n", file, err)
+		return 65
+	}
+	return exitStatus(err)
+}
+
+// exitStatus returns the exit status for the error returned by running a script
+func exitStatus(err error) int {
+	var staticErr lox.StaticError
+	if errors.As(err, &staticErr) {
+		return 65
+	} else if err != nil {
+		return 70
+	}
+	return 0
+}
+
+// disasmFile prints the bytecode of the script instead of running it
+func (c *cli) disasmFile(path string) {
+	if isCompiled(path) {
+		loadCompiled(path).Disassemble(os.Stdout)
+		return
+	}
+
+	program, err := c.interpreter.Compile(path, readSource(path))
+	if err != nil {
+		os.Exit(65)
+	}
+	program.Disassemble(os.Stdout)
+}
+
+func (c *cli) compileCommand(args []string) {
+	flags := flag.NewFlagSet("compile", flag.ExitOnError)
+	output := flags.String("o", "", "output file, defaults to the script with a .loxc extension")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(flags.Output(), "Usage: glox compile [-o output.loxc] script.lox")
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	c.compileFile(flags.Arg(0), *output)
+}
+
+// compileFile compiles the script and writes the bytecode to a .loxc file
+func (c *cli) compileFile(path string, output string) {
+	program, err := c.interpreter.Compile(path, readSource(path))
+	if err != nil {
+		os.Exit(65)
+	}
+
+	if output == "" {
+		output = strings.TrimSuffix(path, filepath.Ext(path)) + ".loxc"
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	if err := program.Save(file); err != nil {
+		panic(fmt.Errorf("writing %s: %w", output, err))
+	}
+}
+
+func (c *cli) astCommand(args []string) {
+	flags := flag.NewFlagSet("ast", flag.ExitOnError)
+	format := flags.String("format", "sexpr", `output format, one of "sexpr", "json" or "dot"`)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(flags.Output(), "Usage: glox ast [--format=sexpr|json|dot] script.lox")
+		flags.PrintDefaults()
+		os.Exit(64)
+	}
+	c.astFile(flags.Arg(0), *format)
+}
+
+// astFile parses the script and prints its syntax tree, without running it
+func (c *cli) astFile(path string, format string) {
+	err := c.interpreter.PrintAST(os.Stdout, path, readSource(path), format)
+	var staticErr lox.StaticError
+	if errors.As(err, &staticErr) {
+		os.Exit(65)
+	} else if err != nil {
+		fmt.Println(err)
+		os.Exit(64)
+	}
+}
+
+// loadCompiled reads a .loxc file, and exits if it is not one we can run
+func loadCompiled(path string) *lox.Program {
+	file, err := os.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	program, err := lox.ReadProgram(file)
+	if err != nil {
+		fmt.Printf("Could not load %s: %v\n", path, err)
+		os.Exit(65)
+	}
+	return program
 }
@@ -1,16 +1,104 @@
 #!/usr/bin/env sh
 
+# Every script is run on both backends, they should produce identical output
+BACKENDS="tree vm"
+
 test () {
     SCRIPT_NAME=$1
-    RES=$(go run . lox_scripts/${SCRIPT_NAME})
+    EXP=$2
+
+    for BACKEND in ${BACKENDS}; do
+        RES=$(go run . -backend=${BACKEND} lox_scripts/${SCRIPT_NAME})
+
+        if [ "${RES}" = "${EXP}" ]; then
+            echo "${SCRIPT_NAME} (${BACKEND}): passed"
+        else
+            echo "test failed"
+            echo "${SCRIPT_NAME} (${BACKEND}): expected \"${EXP}\" to be equal to \"${RES}\""
+        fi
+    done
+}
+
+test_disasm () {
+    SCRIPT_NAME=$1
+    EXP=$2
+
+    RES=$(go run . disasm lox_scripts/${SCRIPT_NAME})
+
+    if [ "${RES}" = "${EXP}" ]; then
+        echo "${SCRIPT_NAME} (disasm): passed"
+    else
+        echo "test failed"
+        echo "${SCRIPT_NAME} (disasm): expected \"${EXP}\" to be equal to \"${RES}\""
+    fi
+}
 
+# test_compiled compiles the script to a .loxc file and runs that instead
+test_compiled () {
+    SCRIPT_NAME=$1
     EXP=$2
 
+    LOXC=$(mktemp --suffix=.loxc)
+    go run . compile -o ${LOXC} lox_scripts/${SCRIPT_NAME}
+    RES=$(go run . run ${LOXC})
+    rm -f ${LOXC}
+
+    if [ "${RES}" = "${EXP}" ]; then
+        echo "${SCRIPT_NAME} (compiled): passed"
+    else
+        echo "test failed"
+        echo "${SCRIPT_NAME} (compiled): expected \"${EXP}\" to be equal to \"${RES}\""
+    fi
+}
+
+# test_flags runs the script on the tree backend with extra flags, for
+# options that only the tree-walking interpreter supports
+test_flags () {
+    FLAGS=$1
+    SCRIPT_NAME=$2
+    EXP=$3
+
+    RES=$(go run . ${FLAGS} lox_scripts/${SCRIPT_NAME})
+
+    if [ "${RES}" = "${EXP}" ]; then
+        echo "${SCRIPT_NAME} (${FLAGS}): passed"
+    else
+        echo "test failed"
+        echo "${SCRIPT_NAME} (${FLAGS}): expected \"${EXP}\" to be equal to \"${RES}\""
+    fi
+}
+
+# test_glox_path runs the script on both backends with GLOX_PATH set, for
+# modules found through the search path
+test_glox_path () {
+    DIRS=$1
+    SCRIPT_NAME=$2
+    EXP=$3
+
+    for BACKEND in ${BACKENDS}; do
+        RES=$(GLOX_PATH=${DIRS} go run . -backend=${BACKEND} lox_scripts/${SCRIPT_NAME})
+
+        if [ "${RES}" = "${EXP}" ]; then
+            echo "${SCRIPT_NAME} (${BACKEND}, GLOX_PATH=${DIRS}): passed"
+        else
+            echo "test failed"
+            echo "${SCRIPT_NAME} (${BACKEND}, GLOX_PATH=${DIRS}): expected \"${EXP}\" to be equal to \"${RES}\""
+        fi
+    done
+}
+
+test_ast () {
+    SCRIPT_NAME=$1
+    FORMAT=$2
+    EXP=$3
+
+    RES=$(go run . ast --format=${FORMAT} lox_scripts/${SCRIPT_NAME})
+
     if [ "${RES}" = "${EXP}" ]; then
-        echo "${SCRIPT_NAME}: passed"
+        echo "${SCRIPT_NAME} (ast ${FORMAT}): passed"
     else
         echo "test failed"
-        echo "${SCRIPT_NAME}: expected \"${EXP}\" to be equal to \"${RES}\""
+        echo "${SCRIPT_NAME} (ast ${FORMAT}): expected \"${EXP}\" to be equal to \"${RES}\""
     fi
 }
 
@@ -88,7 +176,870 @@ test "func_fib.lox" "0
 987
 1597
 2584
-4181"
+4181
+6765
+10946
+17711
+28657
+46368
+75025
+121393
+196418
+317811
+514229"
 
 test "closure.lox" "1
 2"
+
+test "global_var_clouser_bug.lox" "global
+global"
+
+test "resolver_errors.lox" "error[E0200]: Can't read local variable in its own initializer.
+ --> lox_scripts/resolver_errors.lox:6:13
+  |
+6 |     var a = a;
+  |             ^
+note: variable declared here
+ --> lox_scripts/resolver_errors.lox:6:9
+  |
+6 |     var a = a;
+  |         -
+
+error[E0201]: Already a variable with this name in this scope.
+  --> lox_scripts/resolver_errors.lox:12:7
+   |
+12 |   var b = 2;
+   |       ^
+note: previous declaration here
+  --> lox_scripts/resolver_errors.lox:11:7
+   |
+11 |   var b = 1;
+   |       -
+
+error[E0202]: Can't return from top-level code.
+  --> lox_scripts/resolver_errors.lox:15:1
+   |
+15 | return \"at top level\";
+   | ^^^^^^"
+
+test "syntax_errors.lox" "error[E0100]: Expect expression.
+ --> lox_scripts/syntax_errors.lox:3:9
+  |
+3 | var a = ;
+  |         ^
+
+error[E0101]: Invalid assignment target.
+ --> lox_scripts/syntax_errors.lox:4:7
+  |
+4 | 1 + 2 = 3;
+  |       ^
+
+error[E0100]: Expect parameter name.
+ --> lox_scripts/syntax_errors.lox:5:7
+  |
+5 | fun f(1) {}
+  |       ^
+
+error[E0100]: Expect ';' after variable declaration.
+ --> lox_scripts/syntax_errors.lox:7:1
+  |
+7 | print b;
+  | ^^^^^
+
+error[E0100]: Expect ')' after expression.
+ --> lox_scripts/syntax_errors.lox:9:11
+  |
+9 |   print (a;
+  |           ^"
+
+test "unexpected_character.lox" "error[E0001]: Unexpected character.
+ --> lox_scripts/unexpected_character.lox:2:12
+  |
+2 | var a = 1; @
+  |            ^
+
+error[E0001]: Unexpected character.
+ --> lox_scripts/unexpected_character.lox:3:16
+  |
+3 |   var b = 2;   #
+  |                ^"
+
+test "class.lox" "The German chocolate cake is delicious!
+Cake
+Cake instance
+12
+13
+true
+0"
+
+test "class_errors.lox" "error[E0204]: Can't use 'this' outside of a class.
+ --> lox_scripts/class_errors.lox:1:7
+  |
+1 | print this;
+  |       ^^^^
+
+error[E0203]: Can't return a value from an initializer.
+ --> lox_scripts/class_errors.lox:5:5
+  |
+5 |     return \"something\";
+  |     ^^^^^^"
+
+test "class_arity.lox" "3
+error: Expected 2 arguments but got 1.
+  --> lox_scripts/class_arity.lox:10:8
+   |
+10 | Point(1);
+   |        ^"
+
+test "inheritance.lox" "Fry until golden brown.
+Pipe full of custard and coat with chocolate.
+doughnut
+A method
+42"
+
+test "inheritance_errors.lox" "error[E0207]: A class can't inherit from itself.
+ --> lox_scripts/inheritance_errors.lox:1:14
+  |
+1 | class Oops < Oops {}
+  |              ^^^^
+
+error[E0206]: Can't use 'super' in a class with no superclass.
+ --> lox_scripts/inheritance_errors.lox:5:5
+  |
+5 |     super.method();
+  |     ^^^^^
+
+error[E0205]: Can't use 'super' outside of a class.
+ --> lox_scripts/inheritance_errors.lox:9:1
+  |
+9 | super.notInAClass();
+  | ^^^^^"
+
+test "inheritance_not_class.lox" "error: Superclass must be a class.
+ --> lox_scripts/inheritance_not_class.lox:3:18
+  |
+3 | class Subclass < NotAClass {}
+  |                  ^^^^^^^^^"
+
+test "return.lox" "3
+3
+<nil>
+true"
+
+test "undefined_variable.lox" "before
+error: Undefined variable 'notDefined'.
+ --> lox_scripts/undefined_variable.lox:3:9
+  |
+3 |   print notDefined;
+  |         ^^^^^^^^^^"
+
+test "stack_trace.lox" "before
+error: Only instances have properties.
+ --> lox_scripts/stack_trace.lox:3:12
+  |
+3 |   return x.field;
+  |            ^^^^^
+stack trace (most recent call first):
+  inner() called at lox_scripts/stack_trace.lox:7:17
+  middle() called at lox_scripts/stack_trace.lox:12:26
+  init() called at lox_scripts/stack_trace.lox:17:6"
+
+test "stack_overflow.lox" "before
+error: Stack overflow.
+ --> lox_scripts/stack_overflow.lox:3:23
+  |
+3 |   return recurse(n + 1);
+  |                       ^
+stack trace (most recent call first):
+  recurse() called at lox_scripts/stack_overflow.lox:3:23
+  recurse() called at lox_scripts/stack_overflow.lox:3:23
+  recurse() called at lox_scripts/stack_overflow.lox:3:23
+  [previous frame repeated 997 more times]
+  recurse() called at lox_scripts/stack_overflow.lox:7:10"
+
+test "closure_upvalues.lox" "initial
+updated
+outside
+1
+2
+Hello, closures
+error: Can only call functions and classes.
+  --> lox_scripts/closure_upvalues.lox:63:18
+   |
+63 | \"not a function\"();
+   |                  ^"
+
+test "lists.lox" "[1, 2, 3]
+4
+[1, \"two\", 3]
+3
+[1, \"two\", 3, 4, 5, 6]
+6
+[1, \"two\", 3, 4, 5]
+[\"first\", 1, \"two\", 3, 4, 5, \"last\"]
+1
+[\"first\", \"two\", 3, 4, 5, \"last\"]
+[\"two\", 3]
+[\"first\", \"two\", 3, 4, 5, \"last\"]
+[]
+0
+[[1, 20], [[1, 20], 4]]
+[0, 1, 4, 9, 16]
+[1, [...]]"
+
+test "list_errors.lox" "3
+error: Index 3 is out of range for a list of length 3.
+ --> lox_scripts/list_errors.lox:4:22
+  |
+4 |     return list[index];
+  |                      ^
+stack trace (most recent call first):
+  get() called at lox_scripts/list_errors.lox:6:16"
+
+test "maps.lox" "{\"ann\": 31, \"bob\": 27}
+31
+{\"ann\": 32, \"bob\": 27, \"cid\": 40}
+3
+true
+true
+false
+false
+{\"ann\": 32, \"cid\": 40}
+[\"ann\", \"cid\", \"bob\"]
+[32, 40, 28]
+one
+one
+string one
+nothing
+{1: \"one\", true: \"yes\", <nil>: \"nothing\", \"1\": \"string one\"}
+{}
+0
+{\"list\": [1, 2, 3], \"map\": {\"a\": 1, \"b\": 2}}
+{\"a\": 3, \"b\": 2, \"c\": 1}"
+
+test "map_errors.lox" "1
+error: Key \"b\" is not in the map.
+ --> lox_scripts/map_errors.lox:3:12
+  |
+3 | print m[\"b\"];
+  |            ^"
+
+test "break_continue.lox" "0
+1
+2
+0
+2
+3
+5
+ab
+ab
+after
+0
+10
+11
+20
+21
+22
+0
+1
+3
+1
+-1"
+
+test "loop_errors.lox" "error[E0208]: Can't use 'break' outside of a loop.
+ --> lox_scripts/loop_errors.lox:1:1
+  |
+1 | break;
+  | ^^^^^
+
+error[E0208]: Can't use 'continue' outside of a loop.
+ --> lox_scripts/loop_errors.lox:4:3
+  |
+4 |   continue;
+  |   ^^^^^^^^
+
+error[E0208]: Can't use 'break' outside of a loop.
+ --> lox_scripts/loop_errors.lox:9:5
+  |
+9 |     break;
+  |     ^^^^^"
+
+test "exceptions.lox" "before
+<error divide by zero>
+divide by zero
+3
+[\"divide() called at lox_scripts/exceptions.lox:7:37\", \"average() called at lox_scripts/exceptions.lox:12:17\"]
+Can only call functions and classes.
+Expected 2 arguments but got 1.
+oops
+42
+[1, 2]
+bad input
+finally after return
+from try
+from finally
+finally sees local
+caught error with local
+0
+finally
+finally
+2
+finally
+finally
+inner caught first
+inner finally
+outer caught second
+rethrowing
+Operand must be a number
+3
+captured
+Stack overflow.
+3
+Undefined property 'missing'."
+
+test "uncaught_throw.lox" "before
+cleaning up
+1
+cleaning up
+error: Negative values are not allowed.
+ --> lox_scripts/uncaught_throw.lox:3:14
+  |
+3 |   if (x < 0) throw \"Negative values are not allowed.\";
+  |              ^^^^^
+stack trace (most recent call first):
+  check() called at lox_scripts/uncaught_throw.lox:9:19
+  process() called at lox_scripts/uncaught_throw.lox:17:11"
+
+test "uncaught_error.lox" "finally
+error: Only instances have properties.
+ --> lox_scripts/uncaught_error.lox:3:12
+  |
+3 |   return x.field;
+  |            ^^^^^
+stack trace (most recent call first):
+  field() called at lox_scripts/uncaught_error.lox:8:12"
+
+test "try_errors.lox" "error[E0100]: Expect 'catch' or 'finally' after try block.
+ --> lox_scripts/try_errors.lox:3:1
+  |
+3 | }
+  | ^
+
+error[E0100]: Expect '(' after 'catch'.
+ --> lox_scripts/try_errors.lox:7:9
+  |
+7 | } catch e {
+  |         ^
+
+error[E0100]: Expect '{' after 'try'.
+  --> lox_scripts/try_errors.lox:10:5
+   |
+10 | try print \"no block\"; catch (e) {}
+   |     ^^^^^
+
+error[E0100]: Expect expression.
+  --> lox_scripts/try_errors.lox:10:23
+   |
+10 | try print \"no block\"; catch (e) {}
+   |                       ^^^^^
+
+error[E0100]: Expect expression.
+  --> lox_scripts/try_errors.lox:12:6
+   |
+12 | throw;
+   |      ^"
+
+test "strings.lox" "tab:	|
+two
+lines
+quote: \"hi\", backslash: \\
+smile: 😀, e acute: é, A: A
+C:\\new\\table
+first line
+  second line, \"quoted\"
+one
+two
+crème brûlée
+こんにちは 世界
+2
+[\"ü\", \"ß\"]
+{\"ключ\": \"значение\"}"
+
+test "string_errors.lox" "error[E0003]: Invalid escape sequence '\\q'.
+ --> lox_scripts/string_errors.lox:1:14
+  |
+1 | var a = \"bad \\q escape\";
+  |              ^^
+
+error[E0003]: Expect '{' after '\\u'.
+ --> lox_scripts/string_errors.lox:2:19
+  |
+2 | var b = \"no brace \\u1F600\";
+  |                   ^^
+
+error[E0003]: Unicode escape must have 1 to 6 hex digits.
+ --> lox_scripts/string_errors.lox:3:19
+  |
+3 | var c = \"too long \\u{1234567}\";
+  |                   ^^^^^^^^^^^
+
+error[E0003]: Unicode escape '\\u{D800}' is not a valid code point.
+ --> lox_scripts/string_errors.lox:4:20
+  |
+4 | var d = \"surrogate \\u{D800}\";
+  |                    ^^^^^^^^
+
+error[E0003]: Expect hex digits and '}' in Unicode escape.
+ --> lox_scripts/string_errors.lox:5:18
+  |
+5 | var e = \"not hex \\u{12G4}\";
+  |                  ^^^^^
+
+error[E0001]: Unexpected character.
+ --> lox_scripts/string_errors.lox:6:5
+  |
+6 | var 😀 = 1;
+  |     ^
+
+error[E0100]: Expect variable name.
+ --> lox_scripts/string_errors.lox:6:7
+  |
+6 | var 😀 = 1;
+  |       ^
+
+error[E0002]: Unterminated raw string.
+ --> lox_scripts/string_errors.lox:7:9
+  |
+7 | var f = \`unterminated
+  |         ^^^^^^^^^^^^^
+
+error[E0100]: Expect expression.
+ --> lox_scripts/string_errors.lox:8:1
+  |
+8 | 
+  | ^"
+
+test "unicode_columns.lox" "error: Operand must be a number
+ --> lox_scripts/unicode_columns.lox:3:12
+  |
+3 | print über - 1;
+  |            ^"
+
+test "interpolation.lox" "Hello Ada, you are 37
+<nil> true 1.5 [1, \"two\"] {\"a\": 1} Point Point instance <fn greet> <native fn Clock()>
+outer inner Ada! done
+map: v
+costs \$5, written as \${price}
+raw \${name}
+Ada is 36!
+true"
+
+test "interpolation_errors.lox" "error[E0100]: Expect expression in string interpolation.
+ --> lox_scripts/interpolation_errors.lox:1:10
+  |
+1 | print \"\${}\";
+  |          ^
+
+error[E0100]: Expect '}' after expression in string interpolation.
+ --> lox_scripts/interpolation_errors.lox:2:12
+  |
+2 | print \"\${1 2}\";
+  |            ^"
+
+test "modules.lox" "loading geometry
+true
+<module geometry>
+3
+12
+3
+3
+2
+100
+pi is 3, helped by helper
+2
+Undefined property 'missing'."
+
+test "module_natives.lox" "Tried to assign undefined variable: Undefined variable 'len'.
+2
+ours
+3"
+
+test "plus_types.lox" "<error Operands must be two numbers or two strings, got list and string.>
+error: Operands must be two numbers or two strings, got string and number.
+ --> lox_scripts/plus_types.lox:6:11
+  |
+6 | print \"a\" + 1;
+  |           ^"
+
+test "import_errors.lox" "Can't find module 'modules/missing.lox'.
+Import cycle: lox_scripts/modules/cycle_a.lox -> lox_scripts/modules/cycle_b.lox -> lox_scripts/modules/cycle_a.lox.
+loading failing
+Operands must be two numbers or two strings, got nil and number.
+loading failing
+error: Operands must be two numbers or two strings, got nil and number.
+ --> lox_scripts/modules/failing.lox:4:14
+  |
+4 |   return nil + 1;
+  |              ^
+stack trace (most recent call first):
+  fail() called at lox_scripts/modules/failing.lox:7:6"
+
+test "import_static_error.lox" "error[E0100]: Expect variable name.
+ --> lox_scripts/modules/broken.lox:3:5
+  |
+3 | var = 1;
+  |     ^"
+
+test "import_syntax_errors.lox" "error[E0100]: Expect module path after 'import'.
+ --> lox_scripts/import_syntax_errors.lox:1:8
+  |
+1 | import modules as m;
+  |        ^^^^^^^
+
+error[E0100]: Expect 'as' after module path.
+ --> lox_scripts/import_syntax_errors.lox:2:30
+  |
+2 | import \"modules/geometry.lox\";
+  |                              ^
+
+error[E0100]: Expect module name after 'as'.
+ --> lox_scripts/import_syntax_errors.lox:3:33
+  |
+3 | import \"modules/geometry.lox\" as;
+  |                                 ^
+
+error[E0100]: Expect ';' after import.
+ --> lox_scripts/import_syntax_errors.lox:5:1
+  |
+5 | print \"done\";
+  | ^^^^^"
+
+test "string_functions.lox" "7
+éll
+😀
+6
+-1
+é
+[\"a\", \"b\", \"\", \"c\"]
+[\"n\", \"é\", \"😀\"]
+a, b, c
+
+one cat two cat
+[padded]
+STRAßE
+école
+true
+false
+ababab
+[]
+65
+233
+A
+😀
+b
+The Quick Brown Fox"
+
+test "string_function_errors.lox" "Substring from 2 to 4 is out of range for a string of length 3.
+Argument 2 to 'substring' must be a whole number, got 1.5.
+Index 1 is out of range for a string of length 1.
+Argument 1 to 'charAt' must be a string, got number.
+Can't join a list with a number at index 1, only strings.
+Can't repeat a string -1 times.
+Can't repeat a string 9000000000000000000 times, the result would be longer than 16777216 bytes.
+ord needs a string of length 1, got one of length 2.
+ord needs a string of length 1, got one of length 0.
+55296 is not a valid code point.
+-1 is not a valid code point.
+Expected 2 arguments but got 1.
+Can't take the length of a boolean.
+error: Argument 1 to 'upper' must be a string, got nil.
+  --> lox_scripts/string_function_errors.lox:16:16
+   |
+16 | print upper(nil);
+   |                ^"
+
+test_flags "-max-steps=1000" "budget_try.lox" "before
+error: Execution stopped: step limit exceeded.
+ --> lox_scripts/budget_try.lox:4:16
+  |
+4 |   while (true) {}
+  |                ^^"
+
+test_flags "-max-steps=1000" "infinite_loop.lox" "before
+error: Execution stopped: step limit exceeded.
+ --> lox_scripts/infinite_loop.lox:4:14
+  |
+4 | while (true) {
+  |              ^"
+
+test_flags "-timeout=50ms" "timeout.lox" "before
+error: Execution stopped: context deadline exceeded.
+ --> lox_scripts/timeout.lox:3:14
+  |
+3 | while (true) {}
+  |              ^^"
+
+test_flags "-max-depth=2000000" "stack_overflow.lox" "-max-depth must be between 1 and 10000, got 2000000"
+
+test_glox_path "lox_scripts/lib" "module_path.lox" "Hello, path!
+error: Can't find module './greetings.lox'.
+ --> lox_scripts/module_path.lox:6:1
+  |
+6 | import \"./greetings.lox\" as local;
+  | ^^^^^^"
+
+test_disasm "closure.lox" "== <script> ==
+0000    1 OP_CLOSURE          0 <fn makeCounter>
+0003    | OP_DEFINE_GLOBAL    1 'makeCounter'
+0006   11 OP_GET_GLOBAL       1 'makeCounter'
+0009    | OP_CALL             0
+0011    | OP_DEFINE_GLOBAL    2 'counter'
+0014   12 OP_GET_GLOBAL       2 'counter'
+0017    | OP_CALL             0
+0019    | OP_POP
+0020   13 OP_GET_GLOBAL       2 'counter'
+0023    | OP_CALL             0
+0025    | OP_POP
+0026    | OP_NIL
+0027    | OP_RETURN
+
+== <fn makeCounter> ==
+0000    2 OP_CONSTANT         0 '0'
+0003    3 OP_CLOSURE          1 <fn count>
+0006    |                     local 1
+0008    8 OP_GET_LOCAL        2
+0010    | OP_RETURN
+0011    | OP_NIL
+0012    | OP_RETURN
+
+== <fn count> ==
+0000    4 OP_GET_UPVALUE      0
+0002    | OP_CONSTANT         0 '1'
+0005    | OP_ADD
+0006    | OP_SET_UPVALUE      0
+0008    | OP_POP
+0009    5 OP_GET_UPVALUE      0
+0011    | OP_PRINT
+0012    | OP_NIL
+0013    | OP_RETURN"
+
+test_compiled "closure_upvalues.lox" "initial
+updated
+outside
+1
+2
+Hello, closures
+error: Can only call functions and classes.
+  --> lox_scripts/closure_upvalues.lox:63:18
+   |
+63 | \"not a function\"();
+   |                  ^"
+
+test_compiled "inheritance.lox" "Fry until golden brown.
+Pipe full of custard and coat with chocolate.
+doughnut
+A method
+42"
+
+test_compiled "lists.lox" "[1, 2, 3]
+4
+[1, \"two\", 3]
+3
+[1, \"two\", 3, 4, 5, 6]
+6
+[1, \"two\", 3, 4, 5]
+[\"first\", 1, \"two\", 3, 4, 5, \"last\"]
+1
+[\"first\", \"two\", 3, 4, 5, \"last\"]
+[\"two\", 3]
+[\"first\", \"two\", 3, 4, 5, \"last\"]
+[]
+0
+[[1, 20], [[1, 20], 4]]
+[0, 1, 4, 9, 16]
+[1, [...]]"
+
+test_compiled "maps.lox" "{\"ann\": 31, \"bob\": 27}
+31
+{\"ann\": 32, \"bob\": 27, \"cid\": 40}
+3
+true
+true
+false
+false
+{\"ann\": 32, \"cid\": 40}
+[\"ann\", \"cid\", \"bob\"]
+[32, 40, 28]
+one
+one
+string one
+nothing
+{1: \"one\", true: \"yes\", <nil>: \"nothing\", \"1\": \"string one\"}
+{}
+0
+{\"list\": [1, 2, 3], \"map\": {\"a\": 1, \"b\": 2}}
+{\"a\": 3, \"b\": 2, \"c\": 1}"
+
+test_compiled "break_continue.lox" "0
+1
+2
+0
+2
+3
+5
+ab
+ab
+after
+0
+10
+11
+20
+21
+22
+0
+1
+3
+1
+-1"
+
+test_compiled "exceptions.lox" "before
+<error divide by zero>
+divide by zero
+3
+[\"divide() called at lox_scripts/exceptions.lox:7:37\", \"average() called at lox_scripts/exceptions.lox:12:17\"]
+Can only call functions and classes.
+Expected 2 arguments but got 1.
+oops
+42
+[1, 2]
+bad input
+finally after return
+from try
+from finally
+finally sees local
+caught error with local
+0
+finally
+finally
+2
+finally
+finally
+inner caught first
+inner finally
+outer caught second
+rethrowing
+Operand must be a number
+3
+captured
+Stack overflow.
+3
+Undefined property 'missing'."
+
+test_compiled "strings.lox" "tab:	|
+two
+lines
+quote: \"hi\", backslash: \\
+smile: 😀, e acute: é, A: A
+C:\\new\\table
+first line
+  second line, \"quoted\"
+one
+two
+crème brûlée
+こんにちは 世界
+2
+[\"ü\", \"ß\"]
+{\"ключ\": \"значение\"}"
+
+test_compiled "interpolation.lox" "Hello Ada, you are 37
+<nil> true 1.5 [1, \"two\"] {\"a\": 1} Point Point instance <fn greet> <native fn Clock()>
+outer inner Ada! done
+map: v
+costs \$5, written as \${price}
+raw \${name}
+Ada is 36!
+true"
+
+test_compiled "modules.lox" "loading geometry
+true
+<module geometry>
+3
+12
+3
+3
+2
+100
+pi is 3, helped by helper
+2
+Undefined property 'missing'."
+
+test_compiled "string_functions.lox" "7
+éll
+😀
+6
+-1
+é
+[\"a\", \"b\", \"\", \"c\"]
+[\"n\", \"é\", \"😀\"]
+a, b, c
+
+one cat two cat
+[padded]
+STRAßE
+école
+true
+false
+ababab
+[]
+65
+233
+A
+😀
+b
+The Quick Brown Fox"
+
+# .loxc files from another version of glox must be rejected
+BAD_LOXC=$(mktemp --suffix=.loxc)
+printf 'LOXC\000\011' > ${BAD_LOXC}
+RES=$(go run . run ${BAD_LOXC})
+if [ "${RES}" = "Could not load ${BAD_LOXC}: unsupported .loxc version 9, expected 8" ]; then
+    echo "bad .loxc version: passed"
+else
+    echo "test failed"
+    echo "bad .loxc version: got \"${RES}\""
+fi
+rm -f ${BAD_LOXC}
+
+test_ast "closure.lox" "sexpr" "(fun makeCounter () (var i 0) (fun count () (; (= i (+ i 1))) (print i)) (return count))
+(var counter (call makeCounter))
+(; (call counter))
+(; (call counter))"
+
+test_ast "uncaught_throw.lox" "sexpr" "(fun check (x) (if (< x 0) (throw \"Negative values are not allowed.\")) (return x))
+(fun process (x) (try (block (return (call check x))) (finally (block (print \"cleaning up\")))))
+(print \"before\")
+(print (call process 1))
+(; (call process (- 1)))
+(print \"unreachable\")"
+
+test_ast "hello_world.lox" "json" "{
+  \"statements\": [
+    {
+      \"expression\": {
+        \"type\": \"Literal\",
+        \"value\": \"Hello, World!\"
+      },
+      \"type\": \"PrintStmt\"
+    }
+  ],
+  \"type\": \"Program\"
+}"
+
+test_ast "hello_world.lox" "dot" "digraph AST {
+  node [shape=box, fontname=\"monospace\"];
+  n0 [label=\"Program\"];
+  n1 [label=\"PrintStmt\"];
+  n2 [label=\"Literal\\nvalue: \\\"Hello, World!\\\"\"];
+  n1 -> n2 [label=\"expression\"];
+  n0 -> n1 [label=\"statements[0]\"];
+}"